@@ -37,7 +37,7 @@ func handleAuth(args []string, debug bool) (string, string, error) {
 
 	a := auth.New(debug)
 	fmt.Fprintf(os.Stderr, "nlm: launching browser to login... (profile:%v)  (set with NLM_BROWSER_PROFILE)\n", profileName)
-	token, cookies, err := a.GetAuth(auth.WithProfileName(profileName))
+	token, cookies, err := a.GetAuth(auth.WithProfileName(profileName), auth.WithUserIndex(userIndex))
 	if err != nil {
 		return "", "", fmt.Errorf("browser auth failed: %w", err)
 	}
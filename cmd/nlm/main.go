@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -13,6 +16,7 @@ import (
 	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
 	"github.com/tmc/nlm/internal/api"
 	"github.com/tmc/nlm/internal/batchexecute"
+	"google.golang.org/protobuf/proto"
 )
 
 // Global flags
@@ -20,6 +24,12 @@ var (
 	authToken string
 	cookies   string
 	debug     bool
+	language  string
+	debugDir  string
+	replayDir string
+	recordDir string
+	userIndex int
+	jsonOut   bool
 )
 
 func main() {
@@ -30,6 +40,13 @@ func main() {
 	flag.StringVar(&authToken, "auth", os.Getenv("NLM_AUTH_TOKEN"), "auth token (or set NLM_AUTH_TOKEN)")
 	flag.StringVar(&cookies, "cookies", os.Getenv("NLM_COOKIES"), "cookies for authentication (or set NLM_COOKIES)")
 	flag.BoolVar(&debug, "debug", false, "enable debug output")
+	flag.StringVar(&debugDir, "debug-dir", os.Getenv("NLM_DEBUG_DIR"), "directory for raw responses saved in debug mode (or set NLM_DEBUG_DIR)")
+	flag.StringVar(&replayDir, "replay-dir", os.Getenv("NLM_REPLAY_DIR"), "answer requests from responses recorded in this directory instead of the network (or set NLM_REPLAY_DIR)")
+	flag.StringVar(&recordDir, "record-dir", os.Getenv("NLM_RECORD_DIR"), "save each request and response to this directory for -replay-dir (or set NLM_RECORD_DIR)")
+	flag.StringVar(&language, "lang", os.Getenv("NLM_LANG"), "language for generated content, e.g. fr-FR (or set NLM_LANG)")
+	defaultUserIndex, _ := strconv.Atoi(os.Getenv("NLM_USER_INDEX"))
+	flag.IntVar(&userIndex, "user-index", defaultUserIndex, "signed-in Google account to use, as in /u/N/ (or set NLM_USER_INDEX)")
+	flag.BoolVar(&jsonOut, "json", false, "print results of list, sources, notes and generate commands as JSON")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nlm <command> [arguments]\n\n")
@@ -37,6 +54,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  list, ls          List all notebooks\n")
 		fmt.Fprintf(os.Stderr, "  create <title>    Create a new notebook\n")
 		fmt.Fprintf(os.Stderr, "  rm <id>           Delete a notebook\n")
+		fmt.Fprintf(os.Stderr, "  stats <id>        Show source and note counts\n")
 		fmt.Fprintf(os.Stderr, "  analytics <id>    Show notebook analytics\n\n")
 
 		fmt.Fprintf(os.Stderr, "Source Commands:\n")
@@ -46,28 +64,35 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  rename-source <source-id> <new-name>  Rename source\n")
 		fmt.Fprintf(os.Stderr, "  check-source <notebook-id> <source-id>  Check source freshness\n")
 		fmt.Fprintf(os.Stderr, "  refresh-source <notebook-id> <source-id>  Refresh source content\n")
+		fmt.Fprintf(os.Stderr, "  enable-source <notebook-id> <source-id>...  Include sources in generations\n")
+		fmt.Fprintf(os.Stderr, "  disable-source <notebook-id> <source-id>...  Exclude sources from generations\n")
 		fmt.Fprintf(os.Stderr, "  batch-sync <notebook-id> [--google-docs-only] [--force]  Batch sync sources\n\n")
 
 		fmt.Fprintf(os.Stderr, "Note Commands:\n")
 		fmt.Fprintf(os.Stderr, "  notes <id>        List notes in notebook\n")
 		fmt.Fprintf(os.Stderr, "  new-note <id> <title>  Create new note\n")
 		fmt.Fprintf(os.Stderr, "  edit-note <id> <note-id> <content>  Edit note\n")
+		fmt.Fprintf(os.Stderr, "  append-note <id> <note-id> <text>  Append text to note\n")
 		fmt.Fprintf(os.Stderr, "  rm-note <note-id>  Remove note\n\n")
 
 		fmt.Fprintf(os.Stderr, "Audio Commands:\n")
 		fmt.Fprintf(os.Stderr, "  audio-create <id> <instructions>  Create audio overview\n")
 		fmt.Fprintf(os.Stderr, "  audio-get <id>    Get audio overview\n")
 		fmt.Fprintf(os.Stderr, "  audio-rm <id>     Delete audio overview\n")
+		fmt.Fprintf(os.Stderr, "  audio-cancel <id> Cancel audio overview generation\n")
 		fmt.Fprintf(os.Stderr, "  audio-share <id>  Share audio overview\n\n")
 
 		fmt.Fprintf(os.Stderr, "Generation Commands:\n")
 		fmt.Fprintf(os.Stderr, "  generate-guide <id>  Generate notebook guide\n")
+		fmt.Fprintf(os.Stderr, "  generate-faq <id>  Generate FAQ from notebook guide\n")
 		fmt.Fprintf(os.Stderr, "  generate-outline <id>  Generate content outline\n")
+		fmt.Fprintf(os.Stderr, "  suggest <id>      Show suggested questions\n")
 		fmt.Fprintf(os.Stderr, "  generate-section <id>  Generate new section\n\n")
 
 		fmt.Fprintf(os.Stderr, "Other Commands:\n")
 		fmt.Fprintf(os.Stderr, "  auth [profile]    Setup authentication\n")
-		fmt.Fprintf(os.Stderr, "  share <id>        Share notebook\n")
+		fmt.Fprintf(os.Stderr, "  whoami            Show the signed-in account\n")
+		fmt.Fprintf(os.Stderr, "  share <id>        Share notebook (--public for anyone with the link)\n")
 		fmt.Fprintf(os.Stderr, "  feedback <msg>    Submit feedback\n")
 		fmt.Fprintf(os.Stderr, "  hb                Send heartbeat\n\n")
 	}
@@ -110,13 +135,31 @@ func run() error {
 			opts = []batchexecute.Option{batchexecute.WithDebug(true)}
 		}
 
-		if err := runCmd(api.New(authToken, cookies, opts...), cmd, args...); err == nil {
+		apiOpts := []api.Option{api.WithRPCOptions(opts...)}
+		if debugDir != "" {
+			apiOpts = append(apiOpts, api.WithRPCOptions(batchexecute.WithDebugDir(debugDir)))
+		}
+		if replayDir != "" {
+			apiOpts = append(apiOpts, api.WithReplayDir(replayDir))
+		}
+		if recordDir != "" {
+			apiOpts = append(apiOpts, api.WithRecordDir(recordDir))
+		}
+		if language != "" {
+			apiOpts = append(apiOpts, api.WithAcceptLanguage(language))
+		}
+		if userIndex > 0 {
+			apiOpts = append(apiOpts, api.WithUserIndex(userIndex))
+		}
+		client := api.New(authToken, cookies, apiOpts...)
+		err := runCmd(client, cmd, args...)
+		client.Close()
+		if err == nil {
 			return nil
 		} else if !errors.Is(err, batchexecute.ErrUnauthorized) {
 			return err
 		}
 
-		var err error
 		if authToken, cookies, err = handleAuth(nil, debug); err != nil {
 			fmt.Fprintf(os.Stderr, "  -> %v\n", err)
 		}
@@ -142,6 +185,11 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 		err = remove(client, args[0])
 
 	// Source operations
+	case "stats":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm stats <notebook-id>")
+		}
+		err = showProjectStats(client, args[0])
 	case "sources":
 		if len(args) != 1 {
 			log.Fatal("usage: nlm sources <notebook-id>")
@@ -174,6 +222,16 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 			log.Fatal("usage: nlm refresh-source <notebook-id> <source-id>")
 		}
 		err = refreshSource(client, args[0], args[1])
+	case "enable-source":
+		if len(args) < 2 {
+			log.Fatal("usage: nlm enable-source <notebook-id> <source-id>...")
+		}
+		err = enableSources(client, args[0], args[1:])
+	case "disable-source":
+		if len(args) < 2 {
+			log.Fatal("usage: nlm disable-source <notebook-id> <source-id>...")
+		}
+		err = disableSources(client, args[0], args[1:])
 	case "batch-sync":
 		if len(args) < 1 || len(args) > 3 {
 			log.Fatal("usage: nlm batch-sync <notebook-id> [--google-docs-only] [--force]")
@@ -207,6 +265,11 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 			log.Fatal("usage: nlm update-note <notebook-id> <note-id> <content> <title>")
 		}
 		err = updateNote(client, args[0], args[1], args[2], args[3])
+	case "append-note":
+		if len(args) != 3 {
+			log.Fatal("usage: nlm append-note <notebook-id> <note-id> <text>")
+		}
+		err = appendNote(client, args[0], args[1], args[2])
 	case "rm-note":
 		if len(args) != 1 {
 			log.Fatal("usage: nlm rm-note <notebook-id> <note-id>")
@@ -229,6 +292,11 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 			log.Fatal("usage: nlm audio-rm <notebook-id>")
 		}
 		err = deleteAudioOverview(client, args[0])
+	case "audio-cancel":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm audio-cancel <notebook-id>")
+		}
+		err = cancelAudioOverview(client, args[0])
 	case "audio-share":
 		if len(args) != 1 {
 			log.Fatal("usage: nlm audio-share <notebook-id>")
@@ -241,6 +309,16 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 			log.Fatal("usage: nlm generate-guide <notebook-id>")
 		}
 		err = generateNotebookGuide(client, args[0])
+	case "generate-faq":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm generate-faq <notebook-id>")
+		}
+		err = generateFAQ(client, args[0])
+	case "suggest":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm suggest <notebook-id>")
+		}
+		err = showSuggestedQuestions(client, args[0])
 	case "generate-outline":
 		if len(args) != 1 {
 			log.Fatal("usage: nlm generate-outline <notebook-id>")
@@ -258,11 +336,18 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 	// 		log.Fatal("usage: nlm analytics <notebook-id>")
 	// 	}
 	// 	err = getAnalytics(client, args[0])
-	// case "share":
-	// 	if len(args) != 1 {
-	// 		log.Fatal("usage: nlm share <notebook-id>")
-	// 	}
-	// 	err = shareNotebook(client, args[0])
+	case "share":
+		if len(args) < 1 || len(args) > 2 {
+			log.Fatal("usage: nlm share <notebook-id> [--public]")
+		}
+		option := api.SharePrivate
+		if len(args) == 2 {
+			if args[1] != "--public" {
+				log.Fatal("usage: nlm share <notebook-id> [--public]")
+			}
+			option = api.SharePublic
+		}
+		err = shareNotebook(client, args[0], option)
 	// case "feedback":
 	// 	if len(args) != 1 {
 	// 		log.Fatal("usage: nlm feedback <message>")
@@ -271,6 +356,8 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 	case "auth":
 		_, _, err = handleAuth(args, debug)
 
+	case "whoami":
+		err = whoami(client)
 	case "hb":
 		err = heartbeat(client)
 	default:
@@ -287,6 +374,9 @@ func list(c *api.Client) error {
 	if err != nil {
 		return err
 	}
+	if jsonOut {
+		return printJSON(&pb.ListRecentlyViewedProjectsResponse{Projects: notebooks})
+	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
 	fmt.Fprintln(w, "ID\tTITLE\tLAST UPDATED")
 	for _, nb := range notebooks {
@@ -298,6 +388,16 @@ func list(c *api.Client) error {
 	return w.Flush()
 }
 
+// printJSON writes a result to stdout as JSON, for the -json flag.
+func printJSON(m proto.Message) error {
+	b, err := api.MarshalResult(m)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
 func create(c *api.Client, title string) error {
 	notebook, err := c.CreateProject(title, "📙")
 	if err != nil {
@@ -308,21 +408,51 @@ func create(c *api.Client, title string) error {
 }
 
 func remove(c *api.Client, id string) error {
-	fmt.Printf("Are you sure you want to delete notebook %s? [y/N] ", id)
-	var response string
-	fmt.Scanln(&response)
-	if !strings.HasPrefix(strings.ToLower(response), "y") {
+	fmt.Printf("Deleting notebook %s cannot be undone. Type its title to confirm: ", id)
+	title, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	title = strings.TrimRight(title, "\r\n")
+	if title == "" {
 		return fmt.Errorf("operation cancelled")
 	}
-	return c.DeleteProjects([]string{id})
+	if err := c.DeleteProjects([]string{id}, []string{title}); err != nil {
+		if errors.Is(err, api.ErrDeleteNotConfirmed) {
+			return fmt.Errorf("title does not match, operation cancelled: %w", err)
+		}
+		return err
+	}
+	return nil
 }
 
 // Source operations
+func showProjectStats(c *api.Client, notebookID string) error {
+	stats, err := c.ProjectStats(notebookID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(w, "Notebook:\t%s (%s)\n", stats.Title, stats.ProjectID)
+	fmt.Fprintf(w, "Sources:\t%d (%d disabled, %d failed)\n", stats.SourceCount, stats.DisabledSources, stats.FailedSources)
+	types := make([]string, 0, len(stats.SourcesByType))
+	for t := range stats.SourcesByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(w, "  %s:\t%d\n", strings.TrimPrefix(t, "SOURCE_TYPE_"), stats.SourcesByType[t])
+	}
+	fmt.Fprintf(w, "Notes:\t%d\n", stats.NoteCount)
+	return w.Flush()
+}
+
 func listSources(c *api.Client, notebookID string) error {
 	p, err := c.GetProject(notebookID)
 	if err != nil {
 		return fmt.Errorf("list sources: %w", err)
 	}
+	if jsonOut {
+		return printJSON(p)
+	}
 
 	// Removed auth check that was masking beprotojson array nesting bug
 
@@ -341,7 +471,7 @@ func listSources(c *api.Client, notebookID string) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
 	fmt.Fprintln(w, "ID\tTITLE\tTYPE\tSTATUS\tLAST UPDATED")
 	for _, src := range p.Sources {
-		status := "enabled"
+		status := "unknown"
 		if src.Settings != nil {
 			status = src.Settings.Status.String()
 		}
@@ -416,6 +546,22 @@ func renameSource(c *api.Client, sourceID, newName string) error {
 	return nil
 }
 
+func enableSources(c *api.Client, notebookID string, sourceIDs []string) error {
+	if err := c.EnableSources(notebookID, sourceIDs); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Enabled %d source(s)\n", len(sourceIDs))
+	return nil
+}
+
+func disableSources(c *api.Client, notebookID string, sourceIDs []string) error {
+	if err := c.DisableSources(notebookID, sourceIDs); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Disabled %d source(s)\n", len(sourceIDs))
+	return nil
+}
+
 // Note operations
 func createNote(c *api.Client, notebookID, title string) error {
 	fmt.Printf("Creating note in notebook %s...\n", notebookID)
@@ -435,6 +581,16 @@ func updateNote(c *api.Client, notebookID, noteID, content, title string) error
 	return nil
 }
 
+func appendNote(c *api.Client, notebookID, noteID, text string) error {
+	fmt.Printf("Appending to note %s...\n", noteID)
+	note, err := c.AppendToNote(notebookID, noteID, text)
+	if err != nil {
+		return fmt.Errorf("append to note: %w", err)
+	}
+	fmt.Printf("✅ Updated note: %s\n", note.Title)
+	return nil
+}
+
 func removeNote(c *api.Client, notebookID, noteID string) error {
 	fmt.Printf("Are you sure you want to remove note %s? [y/N] ", noteID)
 	var response string
@@ -571,6 +727,9 @@ func listNotes(c *api.Client, notebookID string) error {
 	if err != nil {
 		return fmt.Errorf("list notes: %w", err)
 	}
+	if jsonOut {
+		return printJSON(&pb.GetNotesResponse{Notes: notes})
+	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
 	fmt.Fprintln(w, "ID\tTITLE\tLAST MODIFIED")
@@ -578,7 +737,7 @@ func listNotes(c *api.Client, notebookID string) error {
 		fmt.Fprintf(w, "%s\t%s\t%s\n",
 			note.GetSourceId(),
 			note.Title,
-			note.GetMetadata().LastModifiedTime.AsTime().Format(time.RFC3339),
+			note.GetMetadata().GetLastModifiedTime().AsTime().Format(time.RFC3339),
 		)
 	}
 	return w.Flush()
@@ -602,6 +761,10 @@ func getAudioOverview(c *api.Client, projectID string) error {
 	if err != nil {
 		return fmt.Errorf("get audio overview: %w", err)
 	}
+	if result == nil {
+		fmt.Println("No audio overview for this notebook.")
+		return nil
+	}
 
 	if !result.IsReady {
 		fmt.Println("Audio overview is not ready yet. Try again in a few moments.")
@@ -615,14 +778,9 @@ func getAudioOverview(c *api.Client, projectID string) error {
 
 	// Optionally save the audio file
 	if result.AudioData != "" {
-		audioData, err := result.GetAudioBytes()
+		filename, err := result.SaveToFile("audio_overview_" + result.AudioID)
 		if err != nil {
-			return fmt.Errorf("decode audio data: %w", err)
-		}
-
-		filename := fmt.Sprintf("audio_overview_%s.wav", result.AudioID)
-		if err := os.WriteFile(filename, audioData, 0644); err != nil {
-			return fmt.Errorf("save audio file: %w", err)
+			return err
 		}
 		fmt.Printf("  Saved audio to: %s\n", filename)
 	}
@@ -630,6 +788,20 @@ func getAudioOverview(c *api.Client, projectID string) error {
 	return nil
 }
 
+func cancelAudioOverview(c *api.Client, notebookID string) error {
+	if err := c.CancelAudioOverview(notebookID); err != nil {
+		if errors.Is(err, api.ErrAudioAlreadyReady) {
+			return fmt.Errorf("audio overview already generated; use 'nlm audio-rm' to delete it")
+		}
+		if errors.Is(err, api.ErrNoAudioOverview) {
+			return fmt.Errorf("no audio overview is being generated for %s", notebookID)
+		}
+		return err
+	}
+	fmt.Printf("✅ Cancelled audio overview generation\n")
+	return nil
+}
+
 func deleteAudioOverview(c *api.Client, notebookID string) error {
 	fmt.Printf("Are you sure you want to delete the audio overview? [y/N] ")
 	var response string
@@ -662,16 +834,58 @@ func generateNotebookGuide(c *api.Client, notebookID string) error {
 	if err != nil {
 		return fmt.Errorf("generate guide: %w", err)
 	}
+	if jsonOut {
+		return printJSON(guide)
+	}
 	fmt.Printf("Guide:\n%s\n", guide.Content)
 	return nil
 }
 
+func generateFAQ(c *api.Client, notebookID string) error {
+	fmt.Fprintf(os.Stderr, "Generating FAQ...\n")
+	faq, err := c.NotebookFAQ(notebookID)
+	if err != nil {
+		return fmt.Errorf("generate faq: %w", err)
+	}
+	if len(faq) == 0 {
+		fmt.Println("The notebook guide contains no questions.")
+		return nil
+	}
+	for _, item := range faq {
+		fmt.Printf("Q: %s\n", item.Question)
+		if item.Answer != "" {
+			fmt.Printf("A: %s\n", item.Answer)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func showSuggestedQuestions(c *api.Client, notebookID string) error {
+	questions, err := c.SuggestedQuestions(notebookID)
+	if err != nil {
+		return err
+	}
+	if len(questions) == 0 {
+		fmt.Println("No suggested questions yet. Add more sources and try again.")
+		return nil
+	}
+	fmt.Println("Try asking:")
+	for _, q := range questions {
+		fmt.Printf("  - %s\n", q)
+	}
+	return nil
+}
+
 func generateOutline(c *api.Client, notebookID string) error {
 	fmt.Fprintf(os.Stderr, "Generating outline...\n")
 	outline, err := c.GenerateOutline(notebookID)
 	if err != nil {
 		return fmt.Errorf("generate outline: %w", err)
 	}
+	if jsonOut {
+		return printJSON(outline)
+	}
 	fmt.Printf("Outline:\n%s\n", outline.Content)
 	return nil
 }
@@ -682,19 +896,26 @@ func generateSection(c *api.Client, notebookID string) error {
 	if err != nil {
 		return fmt.Errorf("generate section: %w", err)
 	}
+	if jsonOut {
+		return printJSON(section)
+	}
 	fmt.Printf("Section:\n%s\n", section.Content)
 	return nil
 }
 
-// func shareNotebook(c *api.Client, notebookID string) error {
-// 	fmt.Fprintf(os.Stderr, "Generating share link...\n")
-// 	resp, err := c.ShareProject(notebookID)
-// 	if err != nil {
-// 		return fmt.Errorf("share notebook: %w", err)
-// 	}
-// 	fmt.Printf("Share URL: %s\n", resp.ShareUrl)
-// 	return nil
-// }
+func shareNotebook(c *api.Client, notebookID string, option api.ShareOption) error {
+	fmt.Fprintf(os.Stderr, "Generating share link...\n")
+	resp, err := c.ShareNotebook(notebookID, option)
+	if err != nil {
+		return fmt.Errorf("share notebook: %w", err)
+	}
+	visibility := "private"
+	if resp.IsPublic {
+		visibility = "public"
+	}
+	fmt.Printf("Share URL (%s): %s\n", visibility, resp.ShareURL)
+	return nil
+}
 
 // func submitFeedback(c *api.Client, message string) error {
 // 	if err := c.SubmitFeedback(message); err != nil {
@@ -726,14 +947,9 @@ func createAudioOverview(c *api.Client, projectID string, instructions string) e
 
 	// Save audio file if available
 	if result.AudioData != "" {
-		audioData, err := result.GetAudioBytes()
+		filename, err := result.SaveToFile("audio_overview_" + result.AudioID)
 		if err != nil {
-			return fmt.Errorf("decode audio data: %w", err)
-		}
-
-		filename := fmt.Sprintf("audio_overview_%s.wav", result.AudioID)
-		if err := os.WriteFile(filename, audioData, 0644); err != nil {
-			return fmt.Errorf("save audio file: %w", err)
+			return err
 		}
 		fmt.Printf("  Saved audio to: %s\n", filename)
 	}
@@ -741,6 +957,19 @@ func createAudioOverview(c *api.Client, projectID string, instructions string) e
 	return nil
 }
 
+func whoami(c *api.Client) error {
+	info, err := c.GetUserInfo()
+	if err != nil {
+		return fmt.Errorf("get user info: %w", err)
+	}
+	if info.DisplayName != "" {
+		fmt.Printf("Signed in as %s (%s)\n", info.Email, info.DisplayName)
+		return nil
+	}
+	fmt.Printf("Signed in as %s\n", info.Email)
+	return nil
+}
+
 func heartbeat(c *api.Client) error {
 	return nil
 }
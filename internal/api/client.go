@@ -2,21 +2,36 @@
 package api
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
-	"github.com/davecgh/go-spew/spew"
 	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/backoff"
 	"github.com/tmc/nlm/internal/batchexecute"
 	"github.com/tmc/nlm/internal/beprotojson"
 	"github.com/tmc/nlm/internal/rpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Time threshold constants for Google Drive sync analysis
@@ -32,77 +47,589 @@ type Note = pb.Source
 
 // Client handles NotebookLM API interactions.
 type Client struct {
-	rpc *rpc.Client
+	rpc     *rpc.Client
+	rpcOpts []batchexecute.Option
+
+	// projectCache holds ListRecentlyViewedProjects results when
+	// WithProjectListCache is set.
+	projectCacheTTL time.Duration
+	projectCacheMu  sync.Mutex
+	projectCache    []*Notebook
+	projectCachedAt time.Time
+
+	refreshBeforeFreshness bool
+	skipDisabledSources    bool
+	recoverSourceIDs       bool
+	recoveryTimeout        time.Duration
+	recoveryPoll           *PollOptions
+	hashStore              SourceHashStore
+	decoders               map[protoreflect.FullName]Decoder
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRPCOptions passes options through to the underlying batchexecute client.
+func WithRPCOptions(opts ...batchexecute.Option) Option {
+	return func(c *Client) {
+		c.rpcOpts = append(c.rpcOpts, opts...)
+	}
+}
+
+// WithBaseURL sends all requests to baseURL instead of
+// https://notebooklm.google.com, e.g. to test against a mock server.
+func WithBaseURL(baseURL string) Option {
+	return WithRPCOptions(batchexecute.WithBaseURL(baseURL))
+}
+
+// WithRecordDir saves every successful call to dir, keyed by RPC ID and a
+// hash of its arguments, so that WithReplayDir can serve it back, e.g. in
+// deterministic integration tests.
+func WithRecordDir(dir string) Option {
+	return WithRPCOptions(batchexecute.WithRecordDir(dir))
+}
+
+// WithReplayDir answers every call from responses recorded in dir instead
+// of the network, e.g. a directory written by WithRecordDir or one of debug
+// artifacts attached to a bug report. See batchexecute.WithReplayDir for how
+// files are matched.
+func WithReplayDir(dir string) Option {
+	return WithRPCOptions(batchexecute.WithReplayDir(dir))
+}
+
+// WithAutoReauth calls fn for fresh credentials when a request fails because
+// the session expired, then retries that request once. The new credentials
+// are used for all subsequent calls, which keeps long-running jobs alive
+// across token rotation.
+func WithAutoReauth(fn func() (authToken, cookies string, err error)) Option {
+	return WithRPCOptions(batchexecute.WithAutoReauth(fn))
+}
+
+// WithMaxResponseSize fails any call whose response body exceeds n bytes
+// with batchexecute.ErrResponseTooLarge instead of reading it into memory.
+func WithMaxResponseSize(n int64) Option {
+	return WithRPCOptions(batchexecute.WithMaxResponseSize(n))
+}
+
+// WithMaxIdleConnsPerHost keeps up to n idle connections open for reuse,
+// which speeds up bulk operations such as checking the freshness of many
+// sources concurrently. See batchexecute.WithTransport for full control.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return WithRPCOptions(batchexecute.WithMaxIdleConnsPerHost(n))
+}
+
+// WithUserIndex sends requests as the n-th signed-in Google account (the
+// /u/N/ segment of NotebookLM URLs) rather than the first. The credentials
+// must come from a session that has that account signed in.
+func WithUserIndex(n int) Option {
+	return WithRPCOptions(batchexecute.WithUserIndex(n))
+}
+
+// WithFreshnessTimeout bounds each CheckSourceFreshness request to d instead
+// of rpc.DefaultFreshnessTimeout. Zero removes the bound.
+func WithFreshnessTimeout(d time.Duration) Option {
+	return WithRPCOptions(batchexecute.WithTimeoutFor(rpc.RPCCheckSourceFreshness, d))
+}
+
+// WithAcceptLanguage sets the language for all requests, as a BCP-47 tag
+// such as "fr-FR". It is sent both as the Accept-Language header and as the
+// hl URL parameter the web client uses for its UI language, which together
+// steer the language of generated guides, summaries and audio overviews.
+// Per-call settings such as AudioOverviewOptions.Language take precedence.
+func WithAcceptLanguage(tag string) Option {
+	return WithRPCOptions(
+		batchexecute.WithHeaders(map[string]string{"accept-language": tag}),
+		batchexecute.WithURLParams(map[string]string{"hl": tag}),
+	)
+}
+
+// WithProjectListCache caches ListRecentlyViewedProjects results for ttl.
+// The cache is dropped whenever the client creates, mutates, or deletes a
+// project or adds, changes, or removes one of its sources, since cached
+// projects carry their source lists. A zero ttl disables caching, which is the default.
+func WithProjectListCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.projectCacheTTL = ttl
+	}
+}
+
+// WithRefreshBeforeFreshnessCheck makes CheckSourceFreshness trigger a
+// RefreshSource before reading the freshness status. This is off by
+// default: a refresh updates the source's last-update timestamp, and the
+// Google Drive heuristics treat a recent timestamp as evidence that the
+// source is already in sync, so refreshing first hides the very staleness
+// the check is meant to detect.
+func WithRefreshBeforeFreshnessCheck(enabled bool) Option {
+	return func(c *Client) {
+		c.refreshBeforeFreshness = enabled
+	}
+}
+
+// WithSkipDisabledSources leaves sources that were turned off with
+// DisableSources out of status sweeps: SourceStatuses, SourceStatusList,
+// CheckSourcesFreshness and SyncDriveSources. Their freshness does not
+// matter while they are excluded from generation. By default, or with
+// skip set to false, disabled sources are included.
+func WithSkipDisabledSources(skip bool) Option {
+	return func(c *Client) {
+		c.skipDisabledSources = skip
+	}
+}
+
+// WithSourceIDRecovery makes AddSourceFromText, AddSourceFromBase64 (and
+// the file and reader variants built on it) and AddSourceFromURL look the
+// new source up when the AddSources response carries no usable source ID.
+// AddYouTubeSource always looks an empty response up by video ID; the
+// option only makes it skip copies of the video added earlier.
+// The project's source IDs are read before the add, and afterwards it is
+// polled, as set by WithSourceIDRecoveryPoll, until it has a new source with
+// the title or filename that was added, or for web pages a new source stored
+// with the URL that was added; the most recently modified match is returned.
+// Sources that existed before the add are never picked, but two concurrent
+// adds of the same title or URL can still be confused, and each add costs an
+// extra project read, so the option is off by default and strict callers get
+// the extraction error.
+func WithSourceIDRecovery(enabled bool) Option {
+	return func(c *Client) {
+		c.recoverSourceIDs = enabled
+	}
+}
+
+// sourceRecoveryTimeout is how long WithSourceIDRecovery waits for an added
+// source to appear by default.
+const sourceRecoveryTimeout = 30 * time.Second
+
+// WithSourceIDRecoveryPoll sets how long WithSourceIDRecovery waits for an
+// added source to appear in its project, polling as described by opts. The
+// defaults are 30 seconds and DefaultSourcePollOptions.
+func WithSourceIDRecoveryPoll(timeout time.Duration, opts *PollOptions) Option {
+	return func(c *Client) {
+		c.recoveryTimeout = timeout
+		c.recoveryPoll = opts
+	}
+}
+
+// WithSourceHashStore records the content hash of every file uploaded with
+// AddSourceFromFile in store, for later use by LocalSourceNeedsUpdate.
+func WithSourceHashStore(store SourceHashStore) Option {
+	return func(c *Client) {
+		c.hashStore = store
+	}
+}
+
+// Decoder decodes a raw batchexecute payload into m.
+type Decoder func(data []byte, m proto.Message) error
+
+// WithDecoder decodes responses into messages of the same type as msg with
+// dec instead of beprotojson.Unmarshal. It is an escape hatch for when a
+// change in Google's response shape breaks parsing of one message type
+// before the library catches up; dec typically rewrites the payload and
+// then calls beprotojson.Unmarshal itself.
+//
+// This is an advanced option. The raw payloads are undocumented and may
+// change at any time, and which calls decode which message types is not
+// part of the package's stable API.
+func WithDecoder(msg proto.Message, dec Decoder) Option {
+	return func(c *Client) {
+		if c.decoders == nil {
+			c.decoders = make(map[protoreflect.FullName]Decoder)
+		}
+		c.decoders[msg.ProtoReflect().Descriptor().FullName()] = dec
+	}
+}
+
+// unmarshal decodes data into m with the decoder registered for m's type,
+// or beprotojson.Unmarshal if there is none.
+func (c *Client) unmarshal(data []byte, m proto.Message) error {
+	if dec, ok := c.decoders[m.ProtoReflect().Descriptor().FullName()]; ok {
+		return dec(data, m)
+	}
+	return beprotojson.Unmarshal(data, m)
 }
 
 // New creates a new NotebookLM API client.
-func New(authToken, cookies string, opts ...batchexecute.Option) *Client {
+func New(authToken, cookies string, opts ...Option) *Client {
+	c := &Client{recoveryTimeout: sourceRecoveryTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.rpc = rpc.New(authToken, cookies, c.rpcOpts...)
+	return c
+}
+
+// WithCredentials returns a client for another Google account that shares
+// c's HTTP transport and options, which is cheaper than calling New again
+// for every account. The clone has its own credentials and an empty project
+// cache. A WithAutoReauth callback is not carried over, since it would
+// supply c's credentials; use New for accounts that need one. Closing either
+// client releases idle connections of the shared transport but leaves the
+// other usable.
+func (c *Client) WithCredentials(authToken, cookies string) *Client {
 	return &Client{
-		rpc: rpc.New(authToken, cookies, opts...),
+		rpc:                    c.rpc.WithCredentials(authToken, cookies),
+		rpcOpts:                c.rpcOpts,
+		projectCacheTTL:        c.projectCacheTTL,
+		refreshBeforeFreshness: c.refreshBeforeFreshness,
+		skipDisabledSources:    c.skipDisabledSources,
+		recoverSourceIDs:       c.recoverSourceIDs,
+		recoveryTimeout:        c.recoveryTimeout,
+		recoveryPoll:           c.recoveryPoll,
+		hashStore:              c.hashStore,
+		decoders:               c.decoders,
 	}
 }
 
+// doProto executes call and decodes the response into out. Errors are
+// returned unwrapped by operation so callers can prefix their own name.
+func doProto[T proto.Message](c *Client, call rpc.Call, out T) error {
+	resp, err := c.rpc.Do(call)
+	if err != nil {
+		return err
+	}
+
+	// Debug: Print raw response before unmarshaling
+	if c.rpc.Config.Debug {
+		fmt.Fprintf(os.Stderr, "=== %s Raw Response ===\n", rpc.Describe(call.ID))
+		fmt.Fprintf(os.Stderr, "Response length: %d bytes\n", len(resp))
+		fmt.Fprintf(os.Stderr, "Response preview: %s\n", truncateUTF8(resp, 500))
+		fmt.Fprintf(os.Stderr, "================================\n")
+	}
+
+	if len(bytes.TrimSpace(resp)) == 0 {
+		return errEmptyResponse
+	}
+	if err := c.unmarshal(resp, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// errEmptyResponse is returned by doProto when the server sent no payload for
+// the call, which is how batchexecute reports missing or inaccessible objects.
+var errEmptyResponse = errors.New("empty response")
+
+// MarshalResult encodes a result, such as a project from GetProject or a
+// guide from GenerateNotebookGuide, as indented canonical protobuf JSON with
+// named fields. It is meant for output; beprotojson remains the decoder for
+// NotebookLM's positional wire format.
+func MarshalResult(v proto.Message) ([]byte, error) {
+	return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(v)
+}
+
+// truncateUTF8 returns at most n bytes of b as a string, backing off to the
+// previous rune boundary so multi-byte characters are never split.
+func truncateUTF8(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	return string(b[:n])
+}
+
+// Close releases any resources held by the client, such as idle
+// connections. Callers should defer Close once they are done with a client;
+// the client must not be used afterwards.
+func (c *Client) Close() error {
+	c.invalidateProjectCache()
+	return c.rpc.Close()
+}
+
 // Project/Notebook operations
 
 func (c *Client) ListRecentlyViewedProjects() ([]*Notebook, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	if projects, ok := c.cachedProjects(); ok {
+		return projects, nil
+	}
+
+	var response pb.ListRecentlyViewedProjectsResponse
+	if err := doProto(c, rpc.Call{
 		ID:   rpc.RPCListRecentlyViewedProjects,
 		Args: []interface{}{nil, 1},
-	})
-	if err != nil {
+	}, &response); err != nil {
 		return nil, fmt.Errorf("list projects: %w", err)
 	}
+	c.cacheProjects(response.Projects)
+	return response.Projects, nil
+}
 
-	var response pb.ListRecentlyViewedProjectsResponse
-	if err := beprotojson.Unmarshal(resp, &response); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+// ErrNoProjects is returned by ProjectForSource when the account has no
+// notebooks to search.
+var ErrNoProjects = errors.New("no projects")
+
+// ProjectForSource returns the ID of the notebook holding sourceID, for
+// callers such as freshness checks that only have a source ID. It searches
+// the sources listed with each recently viewed project. An account with no
+// notebooks yields ErrNoProjects; one whose notebooks lack the source yields
+// ErrSourceNotFound.
+func (c *Client) ProjectForSource(sourceID string) (string, error) {
+	projects, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return "", fmt.Errorf("find project for source: %w", err)
 	}
-	return response.Projects, nil
+	return projectForSource(projects, sourceID)
+}
+
+func projectForSource(projects []*Notebook, sourceID string) (string, error) {
+	if len(projects) == 0 {
+		return "", fmt.Errorf("find project for source %s: %w", sourceID, ErrNoProjects)
+	}
+	for _, p := range projects {
+		for _, src := range p.GetSources() {
+			if src.GetSourceId().GetSourceId() == sourceID {
+				return p.GetProjectId(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("source %s in %d projects: %w", sourceID, len(projects), ErrSourceNotFound)
+}
+
+// cachedProjects returns a deep copy of the cached project list if caching
+// is enabled and the entry has not expired.
+func (c *Client) cachedProjects() ([]*Notebook, bool) {
+	if c.projectCacheTTL <= 0 {
+		return nil, false
+	}
+	c.projectCacheMu.Lock()
+	defer c.projectCacheMu.Unlock()
+	if c.projectCache == nil || time.Since(c.projectCachedAt) > c.projectCacheTTL {
+		return nil, false
+	}
+	return cloneProjects(c.projectCache), true
 }
 
+func (c *Client) cacheProjects(projects []*Notebook) {
+	if c.projectCacheTTL <= 0 {
+		return
+	}
+	c.projectCacheMu.Lock()
+	c.projectCache = cloneProjects(projects)
+	c.projectCachedAt = time.Now()
+	c.projectCacheMu.Unlock()
+}
+
+// cloneProjects deep-copies projects so that neither the cache nor its
+// callers see each other's changes.
+func cloneProjects(projects []*Notebook) []*Notebook {
+	clones := make([]*Notebook, len(projects))
+	for i, p := range projects {
+		clones[i] = proto.Clone(p).(*Notebook)
+	}
+	return clones
+}
+
+// invalidateProjectCache drops the cached project list after a mutation.
+func (c *Client) invalidateProjectCache() {
+	c.projectCacheMu.Lock()
+	c.projectCache = nil
+	c.projectCacheMu.Unlock()
+}
+
+// CreateProject creates a notebook. The emoji must be empty or a single
+// emoji as accepted by NormalizeEmoji.
 func (c *Client) CreateProject(title string, emoji string) (*Notebook, error) {
-	resp, err := c.rpc.Do(rpc.Call{
-		ID:   rpc.RPCCreateProject,
-		Args: []interface{}{title, emoji},
-	})
+	emoji, err := NormalizeEmoji(emoji)
 	if err != nil {
 		return nil, fmt.Errorf("create project: %w", err)
 	}
-
 	var project pb.Project
-	if err := beprotojson.Unmarshal(resp, &project); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := doProto(c, rpc.Call{
+		ID:   rpc.RPCCreateProject,
+		Args: []interface{}{title, emoji},
+	}, &project); err != nil {
+		return nil, fmt.Errorf("create project: %w", err)
 	}
+	c.invalidateProjectCache()
 	return &project, nil
 }
 
-func (c *Client) GetProject(projectID string) (*Notebook, error) {
-	resp, err := c.rpc.Do(rpc.Call{
-		ID:         rpc.RPCGetProject,
-		Args:       []interface{}{projectID},
-		NotebookID: projectID,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("get project: %w", err)
+// ErrInvalidNotebookID is returned for a project argument that is neither a
+// notebook ID nor a notebook URL.
+var ErrInvalidNotebookID = errors.New("not a valid notebook ID or URL")
+
+// ParseNotebookURL extracts the notebook ID from a notebook URL such as
+// https://notebooklm.google.com/notebook/<id>?authuser=1, with or without
+// the scheme. A bare notebook ID is returned unchanged.
+func ParseNotebookURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if isNotebookID(s) {
+		return s, nil
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNotebookID, s)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "notebook" && isNotebookID(parts[i+1]) {
+			return parts[i+1], nil
+		}
 	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidNotebookID, s)
+}
 
-	// Debug: Print raw response before unmarshaling
-	if c.rpc.Config.Debug {
-		fmt.Fprintf(os.Stderr, "=== GetProject Raw Response ===\n")
-		fmt.Fprintf(os.Stderr, "Response length: %d bytes\n", len(resp))
-		previewLen := 500
-		if len(resp) < previewLen {
-			previewLen = len(resp)
+// isNotebookID reports whether s looks like a notebook ID. IDs are UUIDs,
+// but any non-empty run of letters, digits, '-' and '_' is accepted.
+func isNotebookID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
 		}
-		fmt.Fprintf(os.Stderr, "Response preview: %s\n", string(resp[:previewLen]))
-		fmt.Fprintf(os.Stderr, "================================\n")
 	}
+	return true
+}
+
+// ErrInvalidSourceID is returned, before any request is sent, for a source
+// ID that cannot be valid.
+var ErrInvalidSourceID = errors.New("not a valid source ID")
+
+// checkSourceIDs rejects source IDs that are obviously malformed, such as
+// empty strings or pasted titles, so that the mistake is reported clearly
+// instead of as a failed RPC. Source IDs are UUIDs, but the check is as
+// lenient as the one for notebook IDs.
+func checkSourceIDs(sourceIDs ...string) error {
+	for _, id := range sourceIDs {
+		if !isNotebookID(id) {
+			return fmt.Errorf("%w: %q", ErrInvalidSourceID, id)
+		}
+	}
+	return nil
+}
+
+// normalizeProjectID lets every method taking a project ID also accept a
+// notebook URL.
+func normalizeProjectID(projectID string) (string, error) {
+	return ParseNotebookURL(projectID)
+}
+
+// ErrInvalidEmoji is returned when a project emoji is not a single emoji.
+var ErrInvalidEmoji = errors.New("invalid emoji")
+
+// NormalizeEmoji trims surrounding space from s and checks that it is exactly
+// one emoji: a single pictograph with optional variation selector, skin tone,
+// or tag sequence, a ZWJ sequence of those, a flag, or a keycap. A lone
+// symbol that defaults to text presentation (e.g. "❤") gets the emoji
+// variation selector appended. The empty string is returned unchanged.
+func NormalizeEmoji(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	runes := []rune(s)
+	i := scanEmoji(runes, 0)
+	for i > 0 && i < len(runes) && runes[i] == '\u200d' {
+		i = scanEmoji(runes, i+1)
+	}
+	if i != len(runes) {
+		return "", fmt.Errorf("%w: %q is not a single emoji", ErrInvalidEmoji, s)
+	}
+	if len(runes) == 1 && runes[0] < 0x1F000 {
+		s += "\ufe0f"
+	}
+	return s, nil
+}
+
+// scanEmoji returns the index just past the emoji element starting at
+// runes[i], or -1 if there is none.
+func scanEmoji(runes []rune, i int) int {
+	at := func(j int) rune {
+		if j < len(runes) {
+			return runes[j]
+		}
+		return -1
+	}
+	isRegional := func(r rune) bool { return r >= 0x1F1E6 && r <= 0x1F1FF }
+
+	r := at(i)
+	switch {
+	case isRegional(r):
+		if !isRegional(at(i + 1)) {
+			return -1
+		}
+		return i + 2
+	case r == '#' || r == '*' || (r >= '0' && r <= '9'):
+		i++
+		if at(i) == '\ufe0f' {
+			i++
+		}
+		if at(i) != '\u20e3' {
+			return -1
+		}
+		return i + 1
+	case !isEmojiBase(r):
+		return -1
+	}
+	i++
+	if at(i) == '\ufe0f' {
+		i++
+	}
+	if r := at(i); r >= 0x1F3FB && r <= 0x1F3FF { // skin tone modifier
+		i++
+	}
+	if r := at(i); r >= 0xE0020 && r <= 0xE007E { // tag sequence, e.g. subdivision flags
+		for r := at(i); r >= 0xE0020 && r <= 0xE007E; r = at(i) {
+			i++
+		}
+		if at(i) != 0xE007F {
+			return -1
+		}
+		i++
+	}
+	return i
+}
+
+func isEmojiBase(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // pictographs, emoticons, transport, supplemental symbols
+		r >= 0x2600 && r <= 0x27BF, // miscellaneous symbols and dingbats
+		r >= 0x2300 && r <= 0x23FF, // technical, e.g. ⌚ ⏰
+		r >= 0x2B00 && r <= 0x2BFF, // arrows and stars, e.g. ⭐
+		r >= 0x2190 && r <= 0x21FF, // arrows
+		r >= 0x25A0 && r <= 0x25FF: // geometric shapes
+		return true
+	}
+	switch r {
+	case 0x00A9, 0x00AE, 0x203C, 0x2049, 0x2122, 0x2139, 0x3030, 0x303D, 0x3297, 0x3299:
+		return true
+	}
+	return false
+}
+
+// ErrProjectNotFound is returned by GetProject when the project does not
+// exist, was deleted, or is not accessible to the current account.
+var ErrProjectNotFound = errors.New("project not found")
 
-	// Sources nesting issue is now fixed in beprotojson package
+// statusNotFound is the NOT_FOUND status code, which GetProject reports for
+// missing projects.
+const statusNotFound = 5
 
+func (c *Client) GetProject(projectID string) (*Notebook, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
 	var project pb.Project
-	if err := beprotojson.Unmarshal(resp, &project); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	err = doProto(c, rpc.Call{
+		ID:         rpc.RPCGetProject,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	}, &project)
+	var rpcErr *batchexecute.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == statusNotFound {
+		err = errEmptyResponse
+	}
+	if errors.Is(err, errEmptyResponse) || (err == nil && project.ProjectId == "") {
+		return nil, fmt.Errorf("get project %s: %w", projectID, ErrProjectNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get project: %w", err)
 	}
 
 	// Debug: Print parsed project after unmarshaling
@@ -121,39 +648,262 @@ func (c *Client) GetProject(projectID string) (*Notebook, error) {
 	return &project, nil
 }
 
-func (c *Client) DeleteProjects(projectIDs []string) error {
-	_, err := c.rpc.Do(rpc.Call{
+// ProjectStats summarizes the contents of a project.
+type ProjectStats struct {
+	ProjectID string `json:"project_id"`
+	Title     string `json:"title"`
+
+	SourceCount int `json:"source_count"`
+	// SourcesByType counts sources by SourceType name, e.g.
+	// "SOURCE_TYPE_GOOGLE_DOCS".
+	SourcesByType   map[string]int `json:"sources_by_type"`
+	DisabledSources int            `json:"disabled_sources"`
+	FailedSources   int            `json:"failed_sources"`
+
+	NoteCount int `json:"note_count"`
+}
+
+// ProjectStats returns source and note counts for a project using
+// GetProject and GetNotes. The API does not report source sizes, so none
+// are included. Nor is whether the project has an audio overview: the only
+// known way to tell is GetAudioOverview, which downloads the audio itself.
+func (c *Client) ProjectStats(projectID string) (*ProjectStats, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("project stats: %w", err)
+	}
+	notes, err := c.GetNotes(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("project stats: %w", err)
+	}
+
+	stats := projectStats(project)
+	stats.NoteCount = len(notes)
+	return stats, nil
+}
+
+func projectStats(project *Notebook) *ProjectStats {
+	stats := &ProjectStats{
+		ProjectID:     project.GetProjectId(),
+		Title:         project.GetTitle(),
+		SourceCount:   len(project.GetSources()),
+		SourcesByType: make(map[string]int),
+	}
+	for _, src := range project.GetSources() {
+		stats.SourcesByType[src.GetMetadata().GetSourceType().String()]++
+		switch src.GetSettings().GetStatus() {
+		case pb.SourceSettings_SOURCE_STATUS_DISABLED:
+			stats.DisabledSources++
+		case pb.SourceSettings_SOURCE_STATUS_ERROR:
+			stats.FailedSources++
+		}
+	}
+	return stats
+}
+
+// ErrDeleteNotConfirmed is returned by DeleteProjects when the titles given
+// to confirm a delete do not match the projects being deleted.
+var ErrDeleteNotConfirmed = errors.New("delete not confirmed")
+
+// DeleteProjects permanently deletes projects. NotebookLM has no trash, so
+// deleted notebooks cannot be restored. To make accidental deletion harder,
+// titles must give the current title of each project, in the same order as
+// projectIDs, much as a user would type a notebook's name to confirm
+// deleting it. If any title does not match, nothing is deleted and
+// ErrDeleteNotConfirmed is returned.
+func (c *Client) DeleteProjects(projectIDs []string, titles []string) error {
+	ids := make([]string, len(projectIDs))
+	for i, projectID := range projectIDs {
+		id, err := normalizeProjectID(projectID)
+		if err != nil {
+			return fmt.Errorf("delete projects: %w", err)
+		}
+		ids[i] = id
+	}
+	if len(titles) != len(ids) {
+		return fmt.Errorf("delete %d projects with %d titles: %w", len(ids), len(titles), ErrDeleteNotConfirmed)
+	}
+	projects, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return fmt.Errorf("delete projects: %w", err)
+	}
+	current := make(map[string]string, len(projects))
+	for _, p := range projects {
+		current[p.GetProjectId()] = p.GetTitle()
+	}
+	for i, id := range ids {
+		title, ok := current[id]
+		if !ok {
+			return fmt.Errorf("delete project %s: %w", id, ErrProjectNotFound)
+		}
+		if title != titles[i] {
+			return fmt.Errorf("delete project %s: title is %q, not %q: %w", id, title, titles[i], ErrDeleteNotConfirmed)
+		}
+	}
+	_, err = c.rpc.Do(rpc.Call{
 		ID:   rpc.RPCDeleteProjects,
-		Args: []interface{}{projectIDs},
+		Args: []interface{}{ids},
 	})
 	if err != nil {
 		return fmt.Errorf("delete projects: %w", err)
 	}
+	c.invalidateProjectCache()
 	return nil
 }
 
+// DeleteProjectsMatching permanently deletes every project for which pred
+// returns true and returns the deleted IDs. It is an error for pred to match nothing, so
+// a mistyped filter is reported rather than silently succeeding.
+func (c *Client) DeleteProjectsMatching(pred func(*Notebook) bool) ([]string, error) {
+	if pred == nil {
+		return nil, fmt.Errorf("delete projects: nil predicate")
+	}
+
+	projects, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return nil, fmt.Errorf("delete projects: %w", err)
+	}
+
+	var ids, titles []string
+	for _, p := range projects {
+		if pred(p) {
+			ids = append(ids, p.ProjectId)
+			titles = append(titles, p.Title)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("delete projects: no projects matched (of %d)", len(projects))
+	}
+
+	if err := c.DeleteProjects(ids, titles); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MutateProject sets the fields given in updates. It is idempotent: the
+// fields are replaced, not combined with their current values, so a call
+// that may or may not have reached the server can simply be repeated.
 func (c *Client) MutateProject(projectID string, updates *pb.Project) (*Notebook, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var project pb.Project
+	if err := doProto(c, rpc.Call{
 		ID:         rpc.RPCMutateProject,
 		Args:       []interface{}{projectID, updates},
 		NotebookID: projectID,
-	})
-	if err != nil {
+	}, &project); err != nil {
 		return nil, fmt.Errorf("mutate project: %w", err)
 	}
+	c.invalidateProjectCache()
+	return &project, nil
+}
 
-	var project pb.Project
-	if err := beprotojson.Unmarshal(resp, &project); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+// SetProjectTitle renames a project. Like the other setters it sends
+// MutateProject only the field it changes, leaving the emoji and the source
+// list untouched. The project proto has no description or cover image
+// fields; title and emoji are the only customizations it carries.
+func (c *Client) SetProjectTitle(projectID, title string) (*Notebook, error) {
+	return c.MutateProject(projectID, &pb.Project{Title: title})
+}
+
+// SetProjectEmoji sets the emoji shown for a project. See SetProjectTitle.
+func (c *Client) SetProjectEmoji(projectID, emoji string) (*Notebook, error) {
+	return c.MutateProject(projectID, &pb.Project{Emoji: emoji})
+}
+
+// SourceOrder returns the IDs of a project's sources in the order the
+// notebook lists them, the order SetProjectSourcesOrder changes. A project
+// without sources yields an empty slice.
+func (c *Client) SourceOrder(projectID string) ([]string, error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("source order: %w", err)
 	}
-	return &project, nil
+	return sourceOrder(project), nil
+}
+
+func sourceOrder(project *Notebook) []string {
+	ids := make([]string, 0, len(project.GetSources()))
+	for _, src := range project.GetSources() {
+		ids = append(ids, src.GetSourceId().GetSourceId())
+	}
+	return ids
+}
+
+// SetProjectSourcesOrder reorders the sources of a project. The given source
+// IDs are placed first, in order; any remaining sources keep their relative
+// order after them, so a single ID pins that source to the top. There is no
+// dedicated reorder RPC, so the new order is sent through MutateProject with
+// the current title and emoji carried over unchanged.
+//
+// This is unverified: no captured web client request shows MutateProject
+// reordering sources, and the server may ignore the order it is sent. Check
+// the result with SourceOrder. It is deliberately not exposed by the CLI.
+func (c *Client) SetProjectSourcesOrder(projectID string, sourceIDs []string) (*Notebook, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("set sources order: %w", err)
+	}
+	updates, err := reorderProjectSources(project, sourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("set sources order: %w", err)
+	}
+	return c.MutateProject(projectID, updates)
+}
+
+// reorderProjectSources returns a copy of project with its sources reordered
+// as described by SetProjectSourcesOrder. All other fields are preserved.
+func reorderProjectSources(project *pb.Project, sourceIDs []string) (*pb.Project, error) {
+	byID := make(map[string]*pb.Source, len(project.Sources))
+	for _, src := range project.Sources {
+		byID[src.GetSourceId().GetSourceId()] = src
+	}
+
+	updated := proto.Clone(project).(*pb.Project)
+	updated.Sources = make([]*pb.Source, 0, len(project.Sources))
+	placed := make(map[string]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		src, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSourceNotInProject, id)
+		}
+		if placed[id] {
+			return nil, fmt.Errorf("duplicate source ID: %s", id)
+		}
+		placed[id] = true
+		updated.Sources = append(updated.Sources, proto.Clone(src).(*pb.Source))
+	}
+	for _, src := range project.Sources {
+		if !placed[src.GetSourceId().GetSourceId()] {
+			updated.Sources = append(updated.Sources, proto.Clone(src).(*pb.Source))
+		}
+	}
+	return updated, nil
 }
 
 func (c *Client) RemoveRecentlyViewedProject(projectID string) error {
-	_, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	_, err = c.rpc.Do(rpc.Call{
 		ID:   rpc.RPCRemoveRecentlyViewed,
 		Args: []interface{}{projectID},
 	})
+	if err == nil {
+		c.invalidateProjectCache()
+	}
 	return err
 }
 
@@ -178,106 +928,759 @@ func (c *Client) AddSources(projectID string, sources []*pb.Source) ([]*pb.Sourc
 }
 */
 
+// SourceStatusUnknown is reported by SourceStatuses for sources whose
+// settings were missing from the response. It lies outside the proto enum,
+// so it cannot be confused with a status the server sent, including
+// SOURCE_STATUS_UNSPECIFIED.
+const SourceStatusUnknown pb.SourceSettings_SourceStatus = -1
+
+// SourceStatuses returns the status of every source in a project, keyed by
+// source ID, from a single GetProject call. Sources without settings map to
+// SourceStatusUnknown rather than being assumed enabled.
+func (c *Client) SourceStatuses(projectID string) (map[string]pb.SourceSettings_SourceStatus, error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("source statuses: %w", err)
+	}
+	statuses := make(map[string]pb.SourceSettings_SourceStatus, len(project.Sources))
+	for _, src := range project.Sources {
+		if c.skipDisabled(src) {
+			continue
+		}
+		statuses[src.GetSourceId().GetSourceId()] = sourceStatus(src)
+	}
+	return statuses, nil
+}
+
+// SourceStatusEntry is the status of one source, as listed by
+// SourceStatusList.
+type SourceStatusEntry struct {
+	SourceID string
+	Status   pb.SourceSettings_SourceStatus
+}
+
+// SourceStatusList is SourceStatuses in a stable order: that of sourceIDs,
+// or the project's own source order if sourceIDs is empty. IDs not found in
+// the project are listed with SourceStatusUnknown.
+func (c *Client) SourceStatusList(projectID string, sourceIDs []string) ([]SourceStatusEntry, error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("source statuses: %w", err)
+	}
+	entries := sourceStatusList(project, sourceIDs)
+	if c.skipDisabledSources {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Status != pb.SourceSettings_SOURCE_STATUS_DISABLED {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	return entries, nil
+}
+
+// sourceStatus returns the status of src, or SourceStatusUnknown if it has
+// no settings.
+func sourceStatus(src *pb.Source) pb.SourceSettings_SourceStatus {
+	if src.GetSettings() == nil {
+		return SourceStatusUnknown
+	}
+	return src.GetSettings().GetStatus()
+}
+
+// skipDisabled reports whether src is left out of status sweeps because of
+// WithSkipDisabledSources.
+func (c *Client) skipDisabled(src *pb.Source) bool {
+	return c.skipDisabledSources && src.GetSettings().GetStatus() == pb.SourceSettings_SOURCE_STATUS_DISABLED
+}
+
+func sourceStatusList(project *Notebook, sourceIDs []string) []SourceStatusEntry {
+	statuses := make(map[string]pb.SourceSettings_SourceStatus, len(project.GetSources()))
+	if len(sourceIDs) == 0 {
+		for _, src := range project.GetSources() {
+			sourceIDs = append(sourceIDs, src.GetSourceId().GetSourceId())
+		}
+	}
+	for _, src := range project.GetSources() {
+		statuses[src.GetSourceId().GetSourceId()] = sourceStatus(src)
+	}
+	entries := make([]SourceStatusEntry, len(sourceIDs))
+	for i, id := range sourceIDs {
+		status, ok := statuses[id]
+		if !ok {
+			status = SourceStatusUnknown
+		}
+		entries[i] = SourceStatusEntry{SourceID: id, Status: status}
+	}
+	return entries
+}
+
+// DiffSources compares the sources of a project against a desired set and
+// reports what would make them match, without changing anything. Desired
+// entries are source titles, or URLs for web page and YouTube sources.
+// toAdd lists the desired entries with no matching source; toRemove lists
+// the IDs of sources matching no desired entry, ready for DeleteSources.
+// Titles and URLs are read from the same GetProject response, so a source
+// added meanwhile cannot show up in one and not the other.
+func (c *Client) DiffSources(projectID string, desired []string) (toAdd, toRemove []string, err error) {
+	projectID, err = normalizeProjectID(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, sources, err := c.projectWithRawSources(projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("diff sources: %w", err)
+	}
+	toAdd, toRemove = diffSources(project, sourceURLs(sources), desired)
+	return toAdd, toRemove, nil
+}
+
+// diffSources matches each source by its title, its YouTube URL, and its
+// web page URL from urls, which is keyed by source ID.
+func diffSources(project *Notebook, urls map[string]string, desired []string) (toAdd, toRemove []string) {
+	want := make(map[string]bool, len(desired))
+	for _, d := range desired {
+		want[d] = true
+	}
+	have := make(map[string]bool)
+	for _, src := range project.GetSources() {
+		id := src.GetSourceId().GetSourceId()
+		keys := []string{src.GetTitle()}
+		if u := src.GetMetadata().GetYoutube().GetYoutubeUrl(); u != "" {
+			keys = append(keys, u)
+		}
+		if u := urls[id]; u != "" {
+			keys = append(keys, u)
+		}
+		matched := false
+		for _, k := range keys {
+			have[k] = true
+			matched = matched || want[k]
+		}
+		if !matched {
+			toRemove = append(toRemove, id)
+		}
+	}
+	for _, d := range desired {
+		if !have[d] {
+			toAdd = append(toAdd, d)
+		}
+	}
+	return toAdd, toRemove
+}
+
+// projectWithRawSources is GetProject that also returns the undecoded
+// source entries, both read from a single response.
+func (c *Client) projectWithRawSources(projectID string) (*Notebook, []interface{}, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:         rpc.RPCGetProject,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	})
+	var rpcErr *batchexecute.RPCError
+	if (errors.As(err, &rpcErr) && rpcErr.Code == statusNotFound) || (err == nil && len(bytes.TrimSpace(resp)) == 0) {
+		return nil, nil, fmt.Errorf("get project %s: %w", projectID, ErrProjectNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("get project: %w", err)
+	}
+	var project pb.Project
+	if err := c.unmarshal(resp, &project); err != nil {
+		return nil, nil, fmt.Errorf("get project: parse response: %w", err)
+	}
+	if project.ProjectId == "" {
+		return nil, nil, fmt.Errorf("get project %s: %w", projectID, ErrProjectNotFound)
+	}
+	sources, err := parseRawSources(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &project, sources, nil
+}
+
+// rawSources returns the undecoded source entries of a GetProject response.
+// Entries have the form [[sourceID], title, metadata, settings].
+func (c *Client) rawSources(projectID string) ([]interface{}, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:         rpc.RPCGetProject,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get project: %w", err)
+	}
+	return parseRawSources(resp)
+}
+
+// parseRawSources returns the source entries of a raw GetProject response.
+func parseRawSources(resp json.RawMessage) ([]interface{}, error) {
+	var data []interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, fmt.Errorf("parse response JSON: %w", err)
+	}
+	var project []interface{}
+	if len(data) > 0 {
+		project, _ = data[0].([]interface{})
+	}
+	var sources []interface{}
+	if len(project) > 1 {
+		sources, _ = project[1].([]interface{})
+	}
+	return sources, nil
+}
+
+// sourceURLs returns the page URL of every web page source among raw
+// source entries, keyed by source ID. SourceMetadata has no field for it,
+// so it is read from the raw response.
+func sourceURLs(sources []interface{}) map[string]string {
+	urls := make(map[string]string)
+	for _, s := range sources {
+		src, ok := s.([]interface{})
+		if !ok || len(src) == 0 {
+			continue
+		}
+		if u := rawSourceURL(src); u != "" {
+			urls[firstString(src[0])] = u
+		}
+	}
+	return urls
+}
+
+// rawSourceURL returns the page URL of a raw web page source, which the
+// metadata (index 2) holds as [url] at index 7, after the YouTube slot at
+// index 5. It returns "" for other kinds of source.
+func rawSourceURL(src []interface{}) string {
+	if len(src) < 3 {
+		return ""
+	}
+	meta, _ := src[2].([]interface{})
+	if len(meta) < 8 {
+		return ""
+	}
+	link, _ := meta[7].([]interface{})
+	if len(link) == 0 {
+		return ""
+	}
+	u, _ := link[0].(string)
+	return u
+}
+
+// rawSourceWordCount returns the word count of a raw source, which its
+// metadata holds at index 1, e.g. 15108 for an hour-long YouTube
+// transcript, or 0 if it is missing. The proto maps that slot to
+// last_update_time_seconds, a name that does not fit what the server sends,
+// so it is read here rather than through the decoded Source.
+func rawSourceWordCount(src []interface{}) int {
+	if len(src) < 3 {
+		return 0
+	}
+	meta, _ := src[2].([]interface{})
+	if len(meta) < 2 {
+		return 0
+	}
+	n, _ := meta[1].(float64)
+	return int(n)
+}
+
+// rawSourceModified returns the last modified time of a raw source, which
+// its metadata holds as [seconds, nanos] at index 2, or the zero time.
+func rawSourceModified(src []interface{}) time.Time {
+	if len(src) < 3 {
+		return time.Time{}
+	}
+	meta, _ := src[2].([]interface{})
+	if len(meta) < 3 {
+		return time.Time{}
+	}
+	ts, _ := meta[2].([]interface{})
+	if len(ts) == 0 {
+		return time.Time{}
+	}
+	sec, _ := ts[0].(float64)
+	var nanos float64
+	if len(ts) > 1 {
+		nanos, _ = ts[1].(float64)
+	}
+	return time.Unix(int64(sec), int64(nanos))
+}
+
 func (c *Client) DeleteSources(projectID string, sourceIDs []string) error {
-	_, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	if err := checkSourceIDs(sourceIDs...); err != nil {
+		return err
+	}
+	_, err = c.rpc.Do(rpc.Call{
 		ID: rpc.RPCDeleteSources,
 		Args: []interface{}{
 			[][][]string{{sourceIDs}},
 		},
-		NotebookID: projectID,
-	})
-	return err
+		NotebookID: projectID,
+	})
+	if err == nil {
+		c.invalidateProjectCache()
+	}
+	return err
+}
+
+// MutateSource sets the fields given in updates. Like MutateProject it is
+// idempotent and safe to retry. The call carries no notebook context; use
+// MutateSourceInProject when the project is known.
+func (c *Client) MutateSource(sourceID string, updates *pb.Source) (*pb.Source, error) {
+	return c.mutateSource("", sourceID, updates)
+}
+
+// MutateSourceInProject is MutateSource for a source of the given project,
+// which is sent as the call's notebook context like DeleteSources and
+// RefreshSource do.
+func (c *Client) MutateSourceInProject(projectID, sourceID string, updates *pb.Source) (*pb.Source, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return c.mutateSource(projectID, sourceID, updates)
+}
+
+func (c *Client) mutateSource(projectID, sourceID string, updates *pb.Source) (*pb.Source, error) {
+	if err := checkSourceIDs(sourceID); err != nil {
+		return nil, err
+	}
+	var source pb.Source
+	if err := doProto(c, rpc.Call{
+		ID:         rpc.RPCMutateSource,
+		Args:       []interface{}{sourceID, updates},
+		NotebookID: projectID,
+	}, &source); err != nil {
+		return nil, fmt.Errorf("mutate source: %w", err)
+	}
+	c.invalidateProjectCache()
+	return &source, nil
+}
+
+// RefreshSource asks the server to re-sync a source with its origin, such
+// as a Google Doc. The returned source carries the server's last sync time
+// in Metadata.LastModifiedTime (see SourceModifiedTime), read back with
+// LoadSource when the refresh response has none. The sync itself runs in
+// the background, so the time may still be that of the previous sync; use
+// StartSourceRefresh or RefreshSourceAndWait and read the time once the
+// source has settled to record when this refresh took effect.
+func (c *Client) RefreshSource(projectID, sourceID string) (*pb.Source, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSourceIDs(sourceID); err != nil {
+		return nil, err
+	}
+	if c.rpc.Config.Debug {
+		fmt.Printf("Refreshing source %s in project %s\n", sourceID, projectID)
+	}
+
+	// Try multiple endpoints to find the correct one for triggering sync
+	endpoints := []struct {
+		name string
+		id   string
+		args []interface{}
+	}{
+		{"MutateSource", rpc.RPCMutateSource, []interface{}{sourceID, map[string]interface{}{"sync": true}}},
+		{"MutateSource_v2", rpc.RPCMutateSource, []interface{}{sourceID, 1}}, // Action type 1
+		{"ActOnSources", rpc.RPCActOnSources, []interface{}{[]string{sourceID}, "sync"}},
+		{"ActOnSources_v2", rpc.RPCActOnSources, []interface{}{[]string{sourceID}, 2}}, // Different action
+		{"RefreshSource", rpc.RPCRefreshSource, []interface{}{sourceID}},
+	}
+
+	var lastResponse []byte
+	var successfulEndpoint string
+
+	for _, endpoint := range endpoints {
+		if c.rpc.Config.Debug {
+			fmt.Printf("Trying %s endpoint...\n", endpoint.name)
+		}
+
+		fullResp, err := c.rpc.DoWithFullResponse(rpc.Call{
+			ID:         endpoint.id,
+			NotebookID: projectID,
+			Args:       endpoint.args,
+		})
+
+		if err != nil {
+			if c.rpc.Config.Debug {
+				fmt.Printf("%s failed: %v\n", endpoint.name, err)
+			}
+			continue
+		}
+
+		if c.rpc.Config.Debug {
+			fmt.Printf("%s response: %s\n", endpoint.name, string(fullResp.Data))
+		}
+
+		lastResponse = fullResp.Data
+		successfulEndpoint = endpoint.name
+
+		// If we get a non-empty response, this might be the right endpoint
+		if len(fullResp.Data) > 2 { // More than just empty array
+			if c.rpc.Config.Debug {
+				fmt.Printf("Got substantial response from %s, using this endpoint\n", endpoint.name)
+			}
+			break
+		}
+	}
+
+	if c.rpc.Config.Debug && successfulEndpoint != "" {
+		fmt.Printf("Successfully called %s for sync trigger\n", successfulEndpoint)
+	}
+
+	// RefreshSource typically returns empty response, but the side effect
+	// is that it triggers background sync. Return a basic source object.
+	var source pb.Source
+
+	// Try to parse the last response
+	if len(lastResponse) > 2 {
+		if err := c.unmarshal(lastResponse, &source); err != nil {
+			if c.rpc.Config.Debug {
+				fmt.Printf("Failed to parse response as Source: %v\n", err)
+			}
+		}
+	}
+
+	// Without a timestamp in the response, read the source back so callers
+	// can tell when the server last synced it.
+	if source.GetMetadata().GetLastModifiedTime() == nil {
+		loaded, err := c.LoadSource(sourceID)
+		if err == nil {
+			return loaded, nil
+		}
+		if c.rpc.Config.Debug {
+			fmt.Printf("Failed to load source after refresh: %v\n", err)
+		}
+	}
+
+	return &source, nil
+}
+
+// ErrSourceProcessing is returned by WaitForSourceReady when the source is
+// still being processed after the timeout elapses.
+var ErrSourceProcessing = errors.New("source still processing")
+
+// ErrSourceFailed is returned when a source settles in the error state.
+var ErrSourceFailed = errors.New("source processing failed")
+
+// PollOptions controls how wait helpers poll for completion. The delay
+// starts at Initial and is multiplied by Multiplier after each poll, up to
+// Max.
+type PollOptions struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// DefaultSourcePollOptions suits source ingestion, which usually settles
+// within seconds.
+var DefaultSourcePollOptions = PollOptions{
+	Initial:    time.Second,
+	Max:        10 * time.Second,
+	Multiplier: 1.5,
+}
+
+// DefaultAudioPollOptions suits audio overview generation, which can take
+// several minutes.
+var DefaultAudioPollOptions = PollOptions{
+	Initial:    10 * time.Second,
+	Max:        time.Minute,
+	Multiplier: 1.5,
+}
+
+// backoff returns the delay sequence described by o. A Multiplier of one
+// or less keeps the delay constant.
+func (o PollOptions) backoff() *backoff.Backoff {
+	m := o.Multiplier
+	if m < 1 {
+		m = 1
+	}
+	return &backoff.Backoff{Initial: o.Initial, Max: o.Max, Multiplier: m}
+}
+
+// poll calls check until it reports done or timeout elapses, sleeping
+// between calls as described by opts. It returns false on timeout.
+func poll(timeout time.Duration, opts *PollOptions, defaults PollOptions, check func() (done bool, err error)) (bool, error) {
+	o := defaults
+	if opts != nil {
+		o = *opts
+	}
+	if o.Initial <= 0 {
+		o.Initial = defaults.Initial
+	}
+
+	deadline := time.Now().Add(timeout)
+	b := o.backoff()
+	for {
+		done, err := check()
+		if err != nil || done {
+			return done, err
+		}
+		delay := b.Next()
+		if time.Now().Add(delay).After(deadline) {
+			return false, nil
+		}
+		time.Sleep(delay)
+	}
+}
+
+// ErrUnsupported is returned by Operation.Cancel for operations the server
+// offers no way to stop.
+var ErrUnsupported = errors.ErrUnsupported
+
+// OperationState is the progress of an Operation.
+type OperationState int
+
+const (
+	// OperationRunning means the work has not settled yet.
+	OperationRunning OperationState = iota
+	// OperationDone means the work finished successfully.
+	OperationDone
+	// OperationFailed means the work settled in an error.
+	OperationFailed
+)
+
+func (s OperationState) String() string {
+	switch s {
+	case OperationRunning:
+		return "running"
+	case OperationDone:
+		return "done"
+	case OperationFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("OperationState(%d)", int(s))
+}
+
+// Operation is a handle on server-side work started by a Start method, such
+// as StartAudioOverview or StartSourceRefresh.
+type Operation struct {
+	status func() (OperationState, error)
+	cancel func() error // nil if the work cannot be cancelled
+	poll   PollOptions
+}
+
+// Status asks the server for the current state of the operation. A failed
+// operation is reported with the error it failed with.
+func (op *Operation) Status() (OperationState, error) {
+	return op.status()
+}
+
+// Wait polls Status until the operation settles or ctx is done. It returns
+// nil once the operation is done, its error if it failed, and ctx.Err() if
+// ctx ends first.
+func (op *Operation) Wait(ctx context.Context) error {
+	b := op.poll.backoff()
+	for {
+		state, err := op.Status()
+		if err != nil || state != OperationRunning {
+			return err
+		}
+		t := time.NewTimer(b.Next())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Cancel stops the operation, or returns ErrUnsupported if it cannot be
+// stopped.
+func (op *Operation) Cancel(ctx context.Context) error {
+	if op.cancel == nil {
+		return ErrUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return op.cancel()
+}
+
+// RefreshSourceAndWait triggers a refresh and then waits for the source as
+// WaitForSourceReady does.
+func (c *Client) RefreshSourceAndWait(projectID, sourceID string, timeout time.Duration, opts *PollOptions) (*pb.Source, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.RefreshSource(projectID, sourceID); err != nil {
+		return nil, fmt.Errorf("refresh source: %w", err)
+	}
+	return c.WaitForSourceReady(sourceID, timeout, opts)
+}
+
+// StartSourceRefresh triggers a refresh of a source and returns a handle
+// that follows the source's status, as WaitForSourceReady does. A refresh
+// cannot be cancelled.
+func (c *Client) StartSourceRefresh(projectID, sourceID string) (*Operation, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.RefreshSource(projectID, sourceID); err != nil {
+		return nil, fmt.Errorf("refresh source: %w", err)
+	}
+	return &Operation{
+		status: func() (OperationState, error) {
+			source, err := c.LoadSource(sourceID)
+			if err != nil {
+				return OperationRunning, fmt.Errorf("source refresh status: %w", err)
+			}
+			switch source.GetSettings().GetStatus() {
+			case pb.SourceSettings_SOURCE_STATUS_ENABLED, pb.SourceSettings_SOURCE_STATUS_DISABLED:
+				return OperationDone, nil
+			case pb.SourceSettings_SOURCE_STATUS_ERROR:
+				return OperationFailed, fmt.Errorf("source %s: %w", sourceID, ErrSourceFailed)
+			}
+			return OperationRunning, nil
+		},
+		poll: DefaultSourcePollOptions,
+	}, nil
 }
 
-func (c *Client) MutateSource(sourceID string, updates *pb.Source) (*pb.Source, error) {
-	resp, err := c.rpc.Do(rpc.Call{
-		ID:   rpc.RPCMutateSource,
-		Args: []interface{}{sourceID, updates},
+// WaitForSourceReady polls LoadSource until the source reports a settled
+// status or timeout elapses. A source that is still processing when the
+// timeout expires yields ErrSourceProcessing; one that settles in
+// SOURCE_STATUS_ERROR yields ErrSourceFailed. Both are returned together
+// with the last source observed. A nil opts uses DefaultSourcePollOptions.
+func (c *Client) WaitForSourceReady(sourceID string, timeout time.Duration, opts *PollOptions) (*pb.Source, error) {
+	var source *pb.Source
+	done, err := poll(timeout, opts, DefaultSourcePollOptions, func() (bool, error) {
+		var err error
+		source, err = c.LoadSource(sourceID)
+		if err != nil {
+			return false, fmt.Errorf("wait for source: %w", err)
+		}
+		switch source.GetSettings().GetStatus() {
+		case pb.SourceSettings_SOURCE_STATUS_ENABLED, pb.SourceSettings_SOURCE_STATUS_DISABLED:
+			return true, nil
+		case pb.SourceSettings_SOURCE_STATUS_ERROR:
+			return false, fmt.Errorf("source %s: %w", sourceID, ErrSourceFailed)
+		}
+		return false, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("mutate source: %w", err)
+		return source, err
 	}
-
-	var source pb.Source
-	if err := beprotojson.Unmarshal(resp, &source); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if !done {
+		return source, fmt.Errorf("source %s after %s: %w", sourceID, timeout, ErrSourceProcessing)
 	}
-	return &source, nil
+	return source, nil
 }
 
-func (c *Client) RefreshSource(projectID, sourceID string) (*pb.Source, error) {
-	if c.rpc.Config.Debug {
-		fmt.Printf("Refreshing source %s in project %s\n", sourceID, projectID)
+// SourceProgress reports how far ingestion of a source has got, from 0 to 1.
+// NotebookLM does not expose the percentage shown in its UI through the
+// source metadata, so the value is coarse and derived from the status: 0
+// before the source has any settings, 0.5 while it is processing, and 1 once
+// it has settled. A source that failed reports 1 along with an error
+// wrapping ErrSourceFailed.
+func (c *Client) SourceProgress(sourceID string) (float64, error) {
+	source, err := c.LoadSource(sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("source progress: %w", err)
 	}
-
-	// Try multiple endpoints to find the correct one for triggering sync
-	endpoints := []struct {
-		name string
-		id   string
-		args []interface{}
-	}{
-		{"MutateSource", rpc.RPCMutateSource, []interface{}{sourceID, map[string]interface{}{"sync": true}}},
-		{"MutateSource_v2", rpc.RPCMutateSource, []interface{}{sourceID, 1}}, // Action type 1
-		{"ActOnSources", rpc.RPCActOnSources, []interface{}{[]string{sourceID}, "sync"}},
-		{"ActOnSources_v2", rpc.RPCActOnSources, []interface{}{[]string{sourceID}, 2}}, // Different action
-		{"RefreshSource", rpc.RPCRefreshSource, []interface{}{sourceID}},
+	if source.GetSettings().GetStatus() == pb.SourceSettings_SOURCE_STATUS_ERROR {
+		return 1, fmt.Errorf("source %s: %w", sourceID, ErrSourceFailed)
 	}
+	return sourceProgress(source), nil
+}
 
-	var lastResponse []byte
-	var successfulEndpoint string
-
-	for _, endpoint := range endpoints {
-		if c.rpc.Config.Debug {
-			fmt.Printf("Trying %s endpoint...\n", endpoint.name)
-		}
-
-		fullResp, err := c.rpc.DoWithFullResponse(rpc.Call{
-			ID:         endpoint.id,
-			NotebookID: projectID,
-			Args:       endpoint.args,
-		})
+func sourceProgress(source *pb.Source) float64 {
+	if source.GetSettings() == nil {
+		return 0
+	}
+	if source.GetSettings().GetStatus() == pb.SourceSettings_SOURCE_STATUS_UNSPECIFIED {
+		return 0.5
+	}
+	return 1
+}
 
-		if err != nil {
-			if c.rpc.Config.Debug {
-				fmt.Printf("%s failed: %v\n", endpoint.name, err)
-			}
-			continue
-		}
+// ErrNoTimestamps is returned when the server reports none of the requested
+// timestamps.
+var ErrNoTimestamps = errors.New("no timestamps reported")
 
-		if c.rpc.Config.Debug {
-			fmt.Printf("%s response: %s\n", endpoint.name, string(fullResp.Data))
-		}
+// ProjectTimestamps returns when a project was created and last modified,
+// as carried in its metadata. A timestamp the server omits is returned as
+// the zero time; if both are omitted the error wraps ErrNoTimestamps.
+func (c *Client) ProjectTimestamps(projectID string) (created, modified time.Time, err error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("project timestamps: %w", err)
+	}
+	created, modified = projectTimestamps(project)
+	if created.IsZero() && modified.IsZero() {
+		return created, modified, fmt.Errorf("project timestamps %s: %w", project.GetProjectId(), ErrNoTimestamps)
+	}
+	return created, modified, nil
+}
 
-		lastResponse = fullResp.Data
-		successfulEndpoint = endpoint.name
+func projectTimestamps(project *Notebook) (created, modified time.Time) {
+	md := project.GetMetadata()
+	return timeOf(md.GetCreateTime()), timeOf(md.GetModifiedTime())
+}
 
-		// If we get a non-empty response, this might be the right endpoint
-		if len(fullResp.Data) > 2 { // More than just empty array
-			if c.rpc.Config.Debug {
-				fmt.Printf("Got substantial response from %s, using this endpoint\n", endpoint.name)
-			}
-			break
-		}
+// SourceModifiedTime returns when a source was last modified, as carried in
+// its metadata. LoadSource responses have not been seen to carry a creation
+// time, so none is reported. If the server omits the modification time the
+// error wraps ErrNoTimestamps.
+func (c *Client) SourceModifiedTime(sourceID string) (time.Time, error) {
+	source, err := c.LoadSource(sourceID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("source modified time: %w", err)
 	}
+	modified := timeOf(source.GetMetadata().GetLastModifiedTime())
+	if modified.IsZero() {
+		return modified, fmt.Errorf("source modified time %s: %w", sourceID, ErrNoTimestamps)
+	}
+	return modified, nil
+}
 
-	if c.rpc.Config.Debug && successfulEndpoint != "" {
-		fmt.Printf("Successfully called %s for sync trigger\n", successfulEndpoint)
+// timeOf converts ts to a time.Time, mapping a missing timestamp to the zero
+// time rather than the Unix epoch that AsTime yields.
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
 	}
+	return ts.AsTime()
+}
 
-	// RefreshSource typically returns empty response, but the side effect
-	// is that it triggers background sync. Return a basic source object.
-	var source pb.Source
+// SourceStats holds size statistics of a source. Counts the server did not
+// report are zero and omitted from JSON.
+type SourceStats struct {
+	// WordCount is the number of words NotebookLM extracted from the source.
+	WordCount int `json:"word_count,omitempty"`
+}
 
-	// Try to parse the last response
-	if len(lastResponse) > 2 {
-		if err := beprotojson.Unmarshal(lastResponse, &source); err != nil {
-			if c.rpc.Config.Debug {
-				fmt.Printf("Failed to parse response as Source: %v\n", err)
-			}
-		}
+// SourceStats returns the size statistics of a source, read from the raw
+// LoadSource response (see rawSourceWordCount). No page count has been seen
+// in LoadSource responses, including for PDFs, so none is reported.
+func (c *Client) SourceStats(sourceID string) (*SourceStats, error) {
+	if err := checkSourceIDs(sourceID); err != nil {
+		return nil, err
+	}
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:   rpc.RPCLoadSource,
+		Args: []interface{}{sourceID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("source stats: %w", err)
+	}
+	var src []interface{}
+	if err := json.Unmarshal(resp, &src); err != nil {
+		return nil, fmt.Errorf("source stats: parse response: %w", err)
 	}
+	return sourceStats(src), nil
+}
 
-	return &source, nil
+func sourceStats(src []interface{}) *SourceStats {
+	return &SourceStats{WordCount: rawSourceWordCount(src)}
 }
 
 // BatchSyncResult represents the result of batch sync operation
@@ -299,6 +1702,10 @@ type SourceSyncResult struct {
 
 // BatchSync performs batch synchronization for all Google Docs sources in a notebook
 func (c *Client) BatchSync(projectID string, googleDocsOnly bool, force bool) (*BatchSyncResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
 	if c.rpc.Config.Debug {
 		fmt.Printf("=== BatchSync called ===\n")
 		fmt.Printf("Project ID: %s\n", projectID)
@@ -337,7 +1744,7 @@ func (c *Client) BatchSync(projectID string, googleDocsOnly bool, force bool) (*
 
 		// Filter Google Docs sources if requested
 		if googleDocsOnly {
-			if source.Metadata == nil || source.Metadata.SourceType != pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS {
+			if source.GetMetadata().GetSourceType() != pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS {
 				syncResult.Status = "SKIPPED"
 				syncResult.Message = "Not a Google Docs source"
 				result.Results = append(result.Results, syncResult)
@@ -391,7 +1798,44 @@ func (c *Client) BatchSync(projectID string, googleDocsOnly bool, force bool) (*
 	return result, nil
 }
 
+// SyncDriveSources triggers a sync with Google Drive for every Drive source
+// of a project that CheckSourceFreshness reports as out of date, like
+// clicking "Sync with Google Drive" on each. The result maps the ID of every
+// source it tried to sync to the error of that attempt, or nil; non-Drive
+// sources and those already in sync are left out. A source whose freshness
+// could not be checked is reported with an error and not synced.
+func (c *Client) SyncDriveSources(projectID string) (map[string]error, error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("sync drive sources: %w", err)
+	}
+	results := make(map[string]error)
+	for _, source := range project.Sources {
+		if !IsDriveSource(source) || c.skipDisabled(source) {
+			continue
+		}
+		sourceID := source.GetSourceId().GetSourceId()
+		freshness, err := c.CheckSourceFreshness(project.ProjectId, sourceID)
+		if err != nil {
+			results[sourceID] = err
+			continue
+		}
+		switch freshness.Status {
+		case pb.SourceSettings_SOURCE_STATUS_ENABLED:
+			continue
+		case pb.SourceSettings_SOURCE_STATUS_ERROR:
+			results[sourceID] = fmt.Errorf("check freshness: %s", freshness.Message)
+			continue
+		}
+		_, results[sourceID] = c.RefreshSource(project.ProjectId, sourceID)
+	}
+	return results, nil
+}
+
 func (c *Client) LoadSource(sourceID string) (*pb.Source, error) {
+	if err := checkSourceIDs(sourceID); err != nil {
+		return nil, err
+	}
 	// Use DoWithFullResponse to get both parsed data and raw response for debugging
 	fullResp, err := c.rpc.DoWithFullResponse(rpc.Call{
 		ID:   rpc.RPCLoadSource,
@@ -414,39 +1858,129 @@ func (c *Client) LoadSource(sourceID string) (*pb.Source, error) {
 	}
 
 	var source pb.Source
-	if err := beprotojson.Unmarshal(fullResp.Data, &source); err != nil {
+	if err := c.unmarshal(fullResp.Data, &source); err != nil {
 		return nil, fmt.Errorf("parse response: %w", err)
 	}
 
 	if c.rpc.Config.Debug {
+		// Freshly added sources may lack settings or metadata; the
+		// generated getters are nil-safe.
 		fmt.Printf("=== Parsed Source Metadata ===\n")
-		if source.SourceId != nil {
-			fmt.Printf("Source ID: %s\n", source.SourceId.SourceId)
-		}
+		fmt.Printf("Source ID: %s\n", source.GetSourceId().GetSourceId())
 		fmt.Printf("Title: %s\n", source.Title)
-		if source.Metadata != nil {
-			fmt.Printf("Source Type: %s\n", source.Metadata.SourceType.String())
-			if gdMeta := source.Metadata.GetGoogleDocs(); gdMeta != nil {
-				fmt.Printf("Google Docs Document ID: %s\n", gdMeta.DocumentId)
-			}
-		}
-		if source.Settings != nil {
-			fmt.Printf("Source Status: %s\n", source.Settings.Status.String())
+		fmt.Printf("Source Type: %s\n", source.GetMetadata().GetSourceType())
+		if gdMeta := source.GetMetadata().GetGoogleDocs(); gdMeta != nil {
+			fmt.Printf("Google Docs Document ID: %s\n", gdMeta.DocumentId)
 		}
+		fmt.Printf("Source Status: %s\n", source.GetSettings().GetStatus())
 		fmt.Printf("==============================\n")
 	}
 
 	return &source, nil
 }
 
-// SourceFreshnessResult represents the result of a source freshness check
+// ErrDownloadUnsupported is returned by DownloadSource.
+var ErrDownloadUnsupported = errors.New("source download not supported")
+
+// DownloadSource would write the original uploaded bytes of a source to w.
+// None of the known NotebookLM RPCs return the uploaded file: AddSources
+// accepts it inline, and LoadSource returns only metadata. Until a download
+// endpoint is found this always returns ErrDownloadUnsupported; keep a local
+// copy of uploads (see WithSourceHashStore for detecting later changes).
+func (c *Client) DownloadSource(sourceID string, w io.Writer) error {
+	return fmt.Errorf("download source %s: %w", sourceID, ErrDownloadUnsupported)
+}
+
+// SourceError returns the reason a source failed processing, such as
+// "unsupported format". It returns "" for sources that are not in the
+// SOURCE_STATUS_ERROR state. The Source proto has no field for the reason,
+// so it is read from the raw LoadSource response.
+func (c *Client) SourceError(sourceID string) (string, error) {
+	if err := checkSourceIDs(sourceID); err != nil {
+		return "", err
+	}
+	fullResp, err := c.rpc.DoWithFullResponse(rpc.Call{
+		ID:   rpc.RPCLoadSource,
+		Args: []interface{}{sourceID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("load source: %w", err)
+	}
+	var source pb.Source
+	if err := c.unmarshal(fullResp.Data, &source); err != nil {
+		return "", fmt.Errorf("parse response: %w", err)
+	}
+	if source.GetSettings().GetStatus() != pb.SourceSettings_SOURCE_STATUS_ERROR {
+		return "", nil
+	}
+	var raw []interface{}
+	if err := json.Unmarshal(fullResp.Data, &raw); err != nil {
+		return "", fmt.Errorf("parse response JSON: %w", err)
+	}
+	return sourceErrorReason(raw, &source), nil
+}
+
+// sourceErrorReason picks the failure reason out of a raw source array. The
+// leading ID, title, and metadata slots are skipped; the first string in the
+// settings or any later slot is taken as the reason. Without one, the
+// numeric warning codes are reported instead.
+func sourceErrorReason(raw []interface{}, source *pb.Source) string {
+	var reason string
+	if len(raw) > 3 {
+		walkStrings(raw[3:], func(s string) {
+			if reason == "" && strings.TrimSpace(s) != "" {
+				reason = strings.TrimSpace(s)
+			}
+		})
+	}
+	if reason != "" {
+		return reason
+	}
+	if warnings := source.GetWarnings(); len(warnings) > 0 {
+		codes := make([]string, len(warnings))
+		for i, w := range warnings {
+			codes[i] = fmt.Sprint(w.GetValue())
+		}
+		return "processing failed (codes " + strings.Join(codes, ", ") + ")"
+	}
+	return "processing failed"
+}
+
+// SourceFreshnessResult represents the result of a source freshness check.
+// Err is set when CheckSourcesFreshness could not check the source at all,
+// for example because its ID is invalid; Status is then
+// SOURCE_STATUS_ERROR and Message carries the error text. Skipped is set
+// for disabled sources left unchecked because of WithSkipDisabledSources.
 type SourceFreshnessResult struct {
-	SourceID string
-	Status   pb.SourceSettings_SourceStatus
-	Message  string
+	SourceID string                         `json:"source_id"`
+	Status   pb.SourceSettings_SourceStatus `json:"status"`
+	Message  string                         `json:"message"`
+	Skipped  bool                           `json:"skipped,omitempty"`
+	Err      error                          `json:"-"`
+}
+
+// MarshalJSON encodes Status by name (e.g. "SOURCE_STATUS_ENABLED") rather
+// than by its numeric value.
+func (r SourceFreshnessResult) MarshalJSON() ([]byte, error) {
+	type plain SourceFreshnessResult
+	return json.Marshal(struct {
+		plain
+		Status string `json:"status"`
+	}{plain(r), r.Status.String()})
 }
 
+// CheckSourceFreshness reports whether a source is in sync with its origin.
+// A check that fails, because the RPC fails or its reply cannot be read, is
+// reported in the result, with Status SOURCE_STATUS_ERROR and Err set; only
+// invalid arguments are returned as an error.
 func (c *Client) CheckSourceFreshness(projectID, sourceID string) (*SourceFreshnessResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSourceIDs(sourceID); err != nil {
+		return nil, err
+	}
 	if c.rpc.Config.Debug {
 		fmt.Printf("=== CheckSourceFreshness called with projectID: %s, sourceID: %s ===\n", projectID, sourceID)
 	}
@@ -455,6 +1989,14 @@ func (c *Client) CheckSourceFreshness(projectID, sourceID string) (*SourceFreshn
 		SourceID: sourceID,
 	}
 
+	// Refreshing first bumps the source's last-update timestamp, which the
+	// timestamp heuristics read as "recently synced", so it is opt-in only.
+	if c.refreshBeforeFreshness {
+		if _, err := c.RefreshSource(projectID, sourceID); err != nil && c.rpc.Config.Debug {
+			fmt.Printf("Pre-check refresh failed: %v\n", err)
+		}
+	}
+
 	// Use CheckSourceFreshness API to get the sync status
 	resp, err := c.rpc.DoWithFullResponse(rpc.Call{
 		ID:         rpc.RPCCheckSourceFreshness,
@@ -464,6 +2006,7 @@ func (c *Client) CheckSourceFreshness(projectID, sourceID string) (*SourceFreshn
 	if err != nil {
 		result.Status = pb.SourceSettings_SOURCE_STATUS_ERROR
 		result.Message = fmt.Sprintf("Failed to check source freshness: %v", err)
+		result.Err = fmt.Errorf("check source freshness: %w", err)
 		return result, nil
 	}
 
@@ -483,12 +2026,62 @@ func (c *Client) CheckSourceFreshness(projectID, sourceID string) (*SourceFreshn
 
 	result.Status = pb.SourceSettings_SOURCE_STATUS_ERROR
 	result.Message = "Could not parse freshness status from API response"
+	result.Err = errors.New("check source freshness: no status in response")
 	return result, nil
 }
 
 
 
 
+// CheckSourcesFreshness checks each source with CheckSourceFreshness and
+// returns the results in the order of sourceIDs. As with the single check,
+// a failed check is reported in its result, with Err set, rather than as an
+// error, and does not stop the others. With
+// WithSkipDisabledSources, disabled sources are not checked; their results
+// have Skipped set and Status SOURCE_STATUS_DISABLED.
+func (c *Client) CheckSourcesFreshness(projectID string, sourceIDs []string) ([]SourceFreshnessResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var disabled map[string]bool
+	if c.skipDisabledSources {
+		project, err := c.GetProject(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("check sources freshness: %w", err)
+		}
+		disabled = make(map[string]bool)
+		for _, src := range project.Sources {
+			if c.skipDisabled(src) {
+				disabled[src.GetSourceId().GetSourceId()] = true
+			}
+		}
+	}
+	results := make([]SourceFreshnessResult, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if disabled[id] {
+			results = append(results, SourceFreshnessResult{
+				SourceID: id,
+				Status:   pb.SourceSettings_SOURCE_STATUS_DISABLED,
+				Message:  "Skipped: source is disabled",
+				Skipped:  true,
+			})
+			continue
+		}
+		result, err := c.CheckSourceFreshness(projectID, id)
+		if err != nil {
+			result = &SourceFreshnessResult{
+				SourceID: id,
+				Status:   pb.SourceSettings_SOURCE_STATUS_ERROR,
+				Message:  err.Error(),
+				Err:      err,
+			}
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
 func (c *Client) interpretFreshnessStatusCode(statusCode int, sourceID string, result *SourceFreshnessResult) (*SourceFreshnessResult, error) {
 	if c.rpc.Config.Debug {
 		fmt.Printf("=== Interpreting Freshness Status Code: %d ===\n", statusCode)
@@ -515,8 +2108,6 @@ func (c *Client) interpretFreshnessStatusCode(statusCode int, sourceID string, r
 
 	// Use generic status code interpretation as fallback
 	return c.genericStatusCodeInterpretation(statusCode, result), nil
-
-	return result, nil
 }
 
 
@@ -539,6 +2130,9 @@ func (c *Client) genericStatusCodeInterpretation(statusCode int, result *SourceF
 }
 
 func (c *Client) extractSourceTitle(sourceArr []interface{}) string {
+	if len(sourceArr) < 2 {
+		return "Unknown Source"
+	}
 	if title, ok := sourceArr[1].(string); ok {
 		return title
 	}
@@ -568,8 +2162,25 @@ func (c *Client) debugMetadata(metadataArr []interface{}) {
 	}
 }
 
+// IsDriveSource reports whether s is backed by a Google Drive file (Docs,
+// Slides or Sheets). It is the typed counterpart of the raw metadata check
+// used by the freshness analyzers and is safe to call on a nil source.
+func IsDriveSource(s *pb.Source) bool {
+	md := s.GetMetadata()
+	if md.GetGoogleDocs() != nil {
+		return true
+	}
+	switch md.GetSourceType() {
+	case pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS,
+		pb.SourceType_SOURCE_TYPE_GOOGLE_SLIDES,
+		pb.SourceType_SOURCE_TYPE_GOOGLE_SHEETS:
+		return true
+	}
+	return false
+}
+
 func (c *Client) isGoogleDriveSource(metadataArr []interface{}) bool {
-	if metadataArr[0] == nil {
+	if len(metadataArr) == 0 || metadataArr[0] == nil {
 		return false
 	}
 	googleDriveInfo, ok := metadataArr[0].([]interface{})
@@ -647,6 +2258,11 @@ func (c *Client) analyzeLength6Metadata(metadataArr []interface{}, result *Sourc
 }
 
 func (c *Client) analyzeLength5Metadata(metadataArr []interface{}, result *SourceFreshnessResult) (*SourceFreshnessResult, error) {
+	if len(metadataArr) < 5 {
+		result.Status = pb.SourceSettings_SOURCE_STATUS_UNSPECIFIED
+		result.Message = "Source status unknown"
+		return result, nil
+	}
 	if c.rpc.Config.Debug {
 		fmt.Printf("Length 5 source - Position [4]: %+v\n", metadataArr[4])
 	}
@@ -748,6 +2364,9 @@ func (c *Client) hasRecentContentChanges(metadataArr []interface{}) bool {
 
 func (c *Client) extractTimestamps(metadataArr []interface{}) (lastUpdate, creation int64) {
 	// Extract timestamps from position [3] and [2]
+	if len(metadataArr) < 4 {
+		return 0, 0
+	}
 	if timestampArr, ok := metadataArr[3].([]interface{}); ok && len(timestampArr) >= 2 {
 		if ts, ok := timestampArr[1].([]interface{}); ok && len(ts) >= 1 {
 			if val, ok := ts[0].(float64); ok {
@@ -825,65 +2444,412 @@ func (c *Client) getStatusMessage(status pb.SourceSettings_SourceStatus) string
 	}
 }
 
-func (c *Client) ActOnSources(projectID string, action string, sourceIDs []string) error {
-	_, err := c.rpc.Do(rpc.Call{
-		ID:         rpc.RPCActOnSources,
-		Args:       []interface{}{projectID, action, sourceIDs},
-		NotebookID: projectID,
+// SourceAction is an action accepted by ActOnSources.
+type SourceAction string
+
+// Known ActOnSources actions. These are the action names sent by the web UI;
+// ActOnSources rejects anything not listed here.
+const (
+	// SourceActionEnable re-includes sources in chat and generations.
+	SourceActionEnable SourceAction = "enable"
+	// SourceActionDisable excludes sources from chat and generations without
+	// deleting them.
+	SourceActionDisable SourceAction = "disable"
+	// SourceActionSync re-syncs Google Drive sources with their documents.
+	SourceActionSync SourceAction = "sync"
+)
+
+// ErrUnknownSourceAction is returned by ActOnSources for an action that is
+// not one of the SourceAction constants.
+var ErrUnknownSourceAction = errors.New("unknown source action")
+
+// Valid reports whether a is one of the known SourceAction constants.
+func (a SourceAction) Valid() bool {
+	switch a {
+	case SourceActionEnable, SourceActionDisable, SourceActionSync:
+		return true
+	}
+	return false
+}
+
+// ActOnSources applies action to the given sources. Unknown actions are
+// rejected before anything is sent, since the server silently ignores them.
+func (c *Client) ActOnSources(projectID string, action SourceAction, sourceIDs []string) error {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	if !action.Valid() {
+		return fmt.Errorf("act on sources: %w: %q", ErrUnknownSourceAction, action)
+	}
+	return c.ActOnSourcesRaw(projectID, string(action), sourceIDs)
+}
+
+// ActOnSourcesRaw is ActOnSources without action validation, for
+// experimenting with action names that have no SourceAction constant yet.
+func (c *Client) ActOnSourcesRaw(projectID string, action string, sourceIDs []string) error {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	if len(sourceIDs) == 0 {
+		return fmt.Errorf("act on sources: no source IDs given")
+	}
+	if err := checkSourceIDs(sourceIDs...); err != nil {
+		return err
+	}
+	_, err = c.rpc.Do(rpc.Call{
+		ID:         rpc.RPCActOnSources,
+		Args:       []interface{}{projectID, action, sourceIDs},
+		NotebookID: projectID,
+	})
+	if err == nil {
+		c.invalidateProjectCache()
+	}
+	return err
+}
+
+// EnableSources re-includes previously disabled sources in chat and
+// generations.
+func (c *Client) EnableSources(projectID string, sourceIDs []string) error {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	if err := c.ActOnSources(projectID, SourceActionEnable, sourceIDs); err != nil {
+		return fmt.Errorf("enable sources: %w", err)
+	}
+	return nil
+}
+
+// DisableSources excludes sources from chat and generations without
+// removing them from the notebook.
+func (c *Client) DisableSources(projectID string, sourceIDs []string) error {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	if err := c.ActOnSources(projectID, SourceActionDisable, sourceIDs); err != nil {
+		return fmt.Errorf("disable sources: %w", err)
+	}
+	return nil
+}
+
+// Source upload utility methods
+
+// MaxUploadSize is the largest file NotebookLM accepts as a single source
+// (200MB). Uploads are sent inline as base64 in one AddSources call; no
+// resumable or chunked upload endpoint is known, so larger inputs are
+// rejected up front with ErrFileTooLarge.
+const MaxUploadSize = 200 << 20
+
+// ErrFileTooLarge is returned when an upload exceeds MaxUploadSize.
+var ErrFileTooLarge = errors.New("file too large")
+
+func (c *Client) AddSourceFromReader(projectID string, r io.Reader, filename string) (string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	return c.addSourceFromReader(projectID, r, filename, nil)
+}
+
+func (c *Client) addSourceFromReader(projectID string, r io.Reader, filename string, headers map[string]string) (string, error) {
+	// Read one byte past the limit so oversized input is detected without
+	// buffering all of it.
+	content, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return "", fmt.Errorf("read content: %w", err)
+	}
+	if len(content) > MaxUploadSize {
+		return "", fmt.Errorf("%s: %w (limit is %d bytes)", filename, ErrFileTooLarge, MaxUploadSize)
+	}
+
+	contentType := http.DetectContentType(content)
+
+	if strings.HasPrefix(contentType, "text/") {
+		return c.addSourceFromText(projectID, string(content), filename, headers)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(content)
+	return c.addSourceFromBase64(projectID, encoded, filename, contentType, headers)
+}
+
+// ErrSourceLimitReached is returned when a source cannot be added because
+// the notebook already holds as many sources as the account allows.
+var ErrSourceLimitReached = errors.New("source limit reached")
+
+// SourceLimitError is the error returned for ErrSourceLimitReached. Limit is
+// the maximum number of sources, or zero if the server did not state it.
+type SourceLimitError struct {
+	Limit int
+}
+
+func (e *SourceLimitError) Error() string {
+	if e.Limit > 0 {
+		return fmt.Sprintf("%s (limit is %d sources)", ErrSourceLimitReached, e.Limit)
+	}
+	return ErrSourceLimitReached.Error()
+}
+
+func (e *SourceLimitError) Unwrap() error {
+	return ErrSourceLimitReached
+}
+
+// limitNumber matches the first number in a limit message.
+var limitNumber = regexp.MustCompile(`\d+`)
+
+// doAddSources runs an AddSources call and returns its payload, converting
+// a cap-exceeded response into a *SourceLimitError.
+func (c *Client) doAddSources(call rpc.Call) (json.RawMessage, error) {
+	resp, err := c.rpc.DoWithFullResponse(call)
+	if err != nil {
+		return nil, err
+	}
+	if err := sourceLimitError(resp.RawArray); err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	c.invalidateProjectCache()
+	return resp.Data, nil
+}
+
+// sourceLimitError inspects the status slot (index 5) of a raw wrb.fr frame.
+// A status whose details mention a source limit is reported as a
+// *SourceLimitError with the first number found in that message as the
+// limit. The status code alone decides nothing: a full notebook is reported
+// as RESOURCE_EXHAUSTED, but so are rate limits and daily quotas, which are
+// left to the generic RPC error.
+func sourceLimitError(frame []interface{}) error {
+	if len(frame) < 6 {
+		return nil
+	}
+	status, ok := frame[5].([]interface{})
+	if !ok || len(status) == 0 {
+		return nil
+	}
+	var (
+		mentioned bool
+		limit     int
+	)
+	walkStrings(status[1:], func(s string) {
+		l := strings.ToLower(s)
+		if !strings.Contains(l, "source") || !(strings.Contains(l, "limit") || strings.Contains(l, "maximum")) {
+			return
+		}
+		mentioned = true
+		if limit == 0 {
+			limit, _ = strconv.Atoi(limitNumber.FindString(s))
+		}
 	})
-	return err
+	if !mentioned {
+		return nil
+	}
+	return &SourceLimitError{Limit: limit}
 }
 
-// Source upload utility methods
+// SourceTypeCode is the type code carried by an AddSources entry.
+type SourceTypeCode int
 
-func (c *Client) AddSourceFromReader(projectID string, r io.Reader, filename string) (string, error) {
-	content, err := io.ReadAll(r)
-	if err != nil {
-		return "", fmt.Errorf("read content: %w", err)
-	}
+// Type codes for AddSources entries. Where the server reports the same kind
+// of source back, the code matches pb.SourceType.
+const (
+	// SourceTypeText marks pasted text. It has no pb.SourceType counterpart.
+	SourceTypeText SourceTypeCode = 2
+	// SourceTypeURL is the type of web page sources. URL entries are
+	// recognized by the position of the URL and carry no code.
+	SourceTypeURL = SourceTypeCode(pb.SourceType_SOURCE_TYPE_WEB_PAGE)
+	// SourceTypeYouTube marks a YouTube video.
+	SourceTypeYouTube = SourceTypeCode(pb.SourceType_SOURCE_TYPE_YOUTUBE_VIDEO)
+)
 
-	contentType := http.DetectContentType(content)
+// TextSourceEntry returns the AddSources entry for pasted text.
+func TextSourceEntry(title, content string) []interface{} {
+	return []interface{}{nil, []string{title, content}, nil, SourceTypeText}
+}
 
-	if strings.HasPrefix(contentType, "text/") {
-		return c.AddSourceFromText(projectID, string(content), filename)
-	}
+// URLSourceEntry returns the AddSources entry for a web page. Use
+// YouTubeSourceEntry for YouTube links.
+func URLSourceEntry(url string) []interface{} {
+	return []interface{}{nil, nil, []string{url}}
+}
 
-	encoded := base64.StdEncoding.EncodeToString(content)
-	return c.AddSourceFromBase64(projectID, encoded, filename, contentType)
+// YouTubeSourceEntry returns the AddSources entry for a YouTube video.
+func YouTubeSourceEntry(videoID string) []interface{} {
+	return []interface{}{
+		nil,               // content
+		nil,               // title
+		videoID,           // video ID (not in array)
+		nil,               // unused
+		SourceTypeYouTube, // source type
+	}
 }
 
+// AddSourceFromText adds content as a text source. An empty title is
+// replaced by one derived from the content (see defaultSourceTitle), since
+// the server otherwise creates an untitled source or rejects the request.
 func (c *Client) AddSourceFromText(projectID string, content, title string) (string, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	return c.addSourceFromText(projectID, content, title, nil)
+}
+
+func (c *Client) addSourceFromText(projectID string, content, title string, headers map[string]string) (string, error) {
+	if strings.TrimSpace(title) == "" {
+		title = defaultSourceTitle(content, time.Now())
+	}
+	before, err := c.recoverySnapshot(projectID)
+	if err != nil {
+		return "", fmt.Errorf("add text source: %w", err)
+	}
+	resp, err := c.doAddSources(rpc.Call{
 		ID:         rpc.RPCAddSources,
 		NotebookID: projectID,
 		Args: []interface{}{
-			[]interface{}{
-				[]interface{}{
-					nil,
-					[]string{
-						title,
-						content,
-					},
-					nil,
-					2, // text source type
-				},
-			},
+			[]interface{}{TextSourceEntry(title, content)},
 			projectID,
 		},
+		Headers: headers,
 	})
 	if err != nil {
 		return "", fmt.Errorf("add text source: %w", err)
 	}
 
-	sourceID, err := extractSourceID(resp)
+	sourceID, err := c.addedSourceID(projectID, resp, title, "", before)
 	if err != nil {
 		return "", fmt.Errorf("extract source ID: %w", err)
 	}
 	return sourceID, nil
 }
 
+// maxDerivedTitleLen is the longest title, in runes, that defaultSourceTitle
+// takes from the content.
+const maxDerivedTitleLen = 80
+
+// defaultSourceTitle returns the first non-blank line of content, truncated
+// to maxDerivedTitleLen runes. Content with no text yields a title stamped
+// with now.
+func defaultSourceTitle(content string, now time.Time) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxDerivedTitleLen {
+			line = strings.TrimSpace(string([]rune(line)[:maxDerivedTitleLen])) + "…"
+		}
+		return line
+	}
+	return "Pasted text " + now.Format("2006-01-02 15:04:05")
+}
+
+// External IDs
+//
+// NotebookLM stores no client-supplied reference with a source, so an
+// external ID is carried in the source title instead, as a prefix:
+//
+//	[ext:<id>] <title>
+//
+// The ID must be non-empty and may not contain whitespace or "]". The
+// prefix is visible in the NotebookLM UI, and renaming the source there
+// loses the ID.
+
+// externalIDPrefix opens the external ID marker in a source title.
+const externalIDPrefix = "[ext:"
+
+// ErrInvalidExternalID is returned for an external ID that cannot be
+// encoded in a title.
+var ErrInvalidExternalID = errors.New("invalid external ID")
+
+// ErrSourceNotFound is returned when a source looked up by external ID or
+// by ID across notebooks does not exist.
+var ErrSourceNotFound = errors.New("source not found")
+
+// ExternalIDTitle returns title prefixed with the marker for extID.
+func ExternalIDTitle(extID, title string) (string, error) {
+	if extID == "" || strings.ContainsAny(extID, "] \t\r\n") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidExternalID, extID)
+	}
+	return strings.TrimSpace(externalIDPrefix + extID + "] " + title), nil
+}
+
+// ParseExternalIDTitle splits a title made by ExternalIDTitle into the
+// external ID and the rest of the title. ok is false if the title carries
+// no ID.
+func ParseExternalIDTitle(title string) (extID, rest string, ok bool) {
+	if !strings.HasPrefix(title, externalIDPrefix) {
+		return "", title, false
+	}
+	extID, rest, ok = strings.Cut(title[len(externalIDPrefix):], "]")
+	if !ok || extID == "" || strings.ContainsAny(extID, " \t\r\n") {
+		return "", title, false
+	}
+	return extID, strings.TrimSpace(rest), true
+}
+
+// AddSourceFromTextWithExternalID is AddSourceFromText with extID encoded
+// in the title, so that FindSourceByExternalID can find the source later.
+func (c *Client) AddSourceFromTextWithExternalID(projectID, content, title, extID string) (string, error) {
+	if strings.TrimSpace(title) == "" {
+		title = defaultSourceTitle(content, time.Now())
+	}
+	title, err := ExternalIDTitle(extID, title)
+	if err != nil {
+		return "", fmt.Errorf("add text source: %w", err)
+	}
+	return c.AddSourceFromText(projectID, content, title)
+}
+
+// FindSourceByExternalID returns the source whose title carries extID. If
+// several do, as after re-uploading, the most recently modified one is
+// returned.
+func (c *Client) FindSourceByExternalID(projectID, extID string) (*pb.Source, error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("find source by external ID: %w", err)
+	}
+	if src := sourceWithExternalID(project, extID); src != nil {
+		return src, nil
+	}
+	return nil, fmt.Errorf("external ID %q: %w", extID, ErrSourceNotFound)
+}
+
+func sourceWithExternalID(project *Notebook, extID string) *pb.Source {
+	var (
+		found  *pb.Source
+		newest time.Time
+	)
+	for _, src := range project.GetSources() {
+		id, _, ok := ParseExternalIDTitle(src.GetTitle())
+		if !ok || id != extID {
+			continue
+		}
+		modified := timeOf(src.GetMetadata().GetLastModifiedTime())
+		if found == nil || modified.After(newest) {
+			found, newest = src, modified
+		}
+	}
+	return found
+}
+
 func (c *Client) AddSourceFromBase64(projectID string, content, filename, contentType string) (string, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	return c.addSourceFromBase64(projectID, content, filename, contentType, nil)
+}
+
+func (c *Client) addSourceFromBase64(projectID string, content, filename, contentType string, headers map[string]string) (string, error) {
+	before, err := c.recoverySnapshot(projectID)
+	if err != nil {
+		return "", fmt.Errorf("add binary source: %w", err)
+	}
+	resp, err := c.doAddSources(rpc.Call{
 		ID:         rpc.RPCAddSources,
 		NotebookID: projectID,
 		Args: []interface{}{
@@ -897,53 +2863,197 @@ func (c *Client) AddSourceFromBase64(projectID string, content, filename, conten
 			},
 			projectID,
 		},
+		Headers: headers,
 	})
 	if err != nil {
 		return "", fmt.Errorf("add binary source: %w", err)
 	}
 
-	sourceID, err := extractSourceID(resp)
+	sourceID, err := c.addedSourceID(projectID, resp, filename, "", before)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, resp)
-		spew.Dump(resp)
-		return "", fmt.Errorf("extract source ID: %w", err)
+		if c.rpc.Config.Debug {
+			fmt.Fprintf(os.Stderr, "AddSources response for %s: %s\n", filename, resp)
+		}
+		return "", fmt.Errorf("add binary source %s: extract source ID: %w (response: %s)", filename, err, truncateUTF8(resp, 200))
 	}
 	return sourceID, nil
 }
 
 func (c *Client) AddSourceFromFile(projectID string, filepath string) (string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	return c.addSourceFromFile(projectID, filepath, nil)
+}
+
+// AddSourceFromFileWithLanguage is like AddSourceFromFile but tells the
+// server the language of the file, as a BCP-47 tag such as "ja", instead of
+// leaving it to auto-detection, which is unreliable for short documents.
+// The AddSources payload has no known language slot, so the hint is sent
+// only as the Accept-Language header of the upload call, as
+// WithAcceptLanguage does for every call. That is a limitation, not a
+// workaround: nothing shows the server reading the header during ingestion,
+// so it may still auto-detect the language. An empty langCode means
+// auto-detect.
+func (c *Client) AddSourceFromFileWithLanguage(projectID, path, langCode string) (string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	langCode = strings.TrimSpace(langCode)
+	if langCode == "" {
+		return c.addSourceFromFile(projectID, path, nil)
+	}
+	return c.addSourceFromFile(projectID, path, map[string]string{"accept-language": langCode})
+}
+
+func (c *Client) addSourceFromFile(projectID string, filepath string, headers map[string]string) (string, error) {
 	f, err := os.Open(filepath)
 	if err != nil {
 		return "", fmt.Errorf("open file: %w", err)
 	}
 	defer f.Close()
 
-	return c.AddSourceFromReader(projectID, f, filepath)
+	if fi, err := f.Stat(); err == nil && fi.Size() > MaxUploadSize {
+		return "", fmt.Errorf("%s: %w (%d bytes, limit is %d bytes)", filepath, ErrFileTooLarge, fi.Size(), MaxUploadSize)
+	}
+
+	// Hash the bytes as they are uploaded rather than re-reading the file
+	// afterwards, which would record a later edit as already uploaded.
+	h := sha256.New()
+	sourceID, err := c.addSourceFromReader(projectID, io.TeeReader(f, h), filepath, headers)
+	if err != nil || c.hashStore == nil {
+		return sourceID, err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	if err := c.hashStore.SetSourceHash(sourceID, hash); err != nil {
+		return sourceID, fmt.Errorf("store source hash: %w", err)
+	}
+	return sourceID, nil
+}
+
+// SourceHashStore persists content hashes of uploaded local files, keyed by
+// source ID. NotebookLM has no notion of freshness for uploaded files, so the
+// caller keeps the hashes, e.g. in a sidecar file next to a sync job.
+type SourceHashStore interface {
+	// SourceHash returns the stored hash, or "" if none is recorded.
+	SourceHash(sourceID string) (string, error)
+	SetSourceHash(sourceID, hash string) error
+}
+
+// SourceHashes is an in-memory SourceHashStore. It marshals to a plain JSON
+// object, so it can be saved and reloaded as a sidecar file.
+type SourceHashes map[string]string
+
+func (h SourceHashes) SourceHash(sourceID string) (string, error) {
+	return h[sourceID], nil
+}
+
+func (h SourceHashes) SetSourceHash(sourceID, hash string) error {
+	h[sourceID] = hash
+	return nil
+}
+
+// HashFile returns the hex-encoded SHA-256 of the file at path, in the form
+// stored by WithSourceHashStore.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// LocalSourceNeedsUpdate reports whether the file at path differs from the
+// content last uploaded as sourceID, according to the client's
+// SourceHashStore. A source with no recorded hash always needs an update.
+func (c *Client) LocalSourceNeedsUpdate(sourceID, path string) (bool, error) {
+	if c.hashStore == nil {
+		return false, fmt.Errorf("local source needs update: no source hash store configured")
+	}
+	stored, err := c.hashStore.SourceHash(sourceID)
+	if err != nil {
+		return false, fmt.Errorf("load source hash: %w", err)
+	}
+	current, err := HashFile(path)
+	if err != nil {
+		return false, err
+	}
+	return stored != current, nil
+}
+
+// URLKind is the kind of source a URL points to, as decided by ClassifyURL.
+type URLKind string
+
+// URL kinds recognized by ClassifyURL.
+const (
+	// URLKindWebPage is any URL not matched by a more specific kind.
+	URLKindWebPage URLKind = "webpage"
+	// URLKindPDF is a direct link to a PDF file.
+	URLKindPDF URLKind = "pdf"
+	// URLKindYouTube is a YouTube video link.
+	URLKindYouTube URLKind = "youtube"
+)
+
+// ClassifyURL reports what kind of source rawURL points to, which decides
+// how AddSourceFromURL ingests it. The decision is made from the URL alone;
+// nothing is fetched. Google Docs and Drive links are web pages: no
+// AddSources payload for Drive files is known.
+func ClassifyURL(rawURL string) URLKind {
+	if isYouTubeURL(rawURL) {
+		return URLKindYouTube
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return URLKindWebPage
+	}
+	if strings.HasSuffix(strings.ToLower(u.Path), ".pdf") {
+		return URLKindPDF
+	}
+	return URLKindWebPage
 }
 
+// AddSourceFromURL adds the page at url as a source, routed by ClassifyURL:
+// YouTube links become video sources and direct PDF links are downloaded
+// and uploaded as files, so the PDF is ingested as a document rather than
+// scraped as a web page. A PDF link that does not serve a PDF, such as one
+// redirecting to a landing page, is added as a web page after all.
 func (c *Client) AddSourceFromURL(projectID string, url string) (string, error) {
-	// Check if it's a YouTube URL first
-	if isYouTubeURL(url) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	switch ClassifyURL(url) {
+	case URLKindYouTube:
 		videoID, err := extractYouTubeVideoID(url)
 		if err != nil {
 			return "", fmt.Errorf("invalid YouTube URL: %w", err)
 		}
-		// Use dedicated YouTube method
 		return c.AddYouTubeSource(projectID, videoID)
+	case URLKindPDF:
+		sourceID, isPDF, err := c.addPDFFromURL(projectID, url)
+		if isPDF || err != nil {
+			return sourceID, err
+		}
 	}
 
 	// Regular URL handling
-	resp, err := c.rpc.Do(rpc.Call{
+	before, err := c.recoverySnapshot(projectID)
+	if err != nil {
+		return "", fmt.Errorf("add source from URL: %w", err)
+	}
+	resp, err := c.doAddSources(rpc.Call{
 		ID:         rpc.RPCAddSources,
 		NotebookID: projectID,
 		Args: []interface{}{
-			[]interface{}{
-				[]interface{}{
-					nil,
-					nil,
-					[]string{url},
-				},
-			},
+			[]interface{}{URLSourceEntry(url)},
 			projectID,
 		},
 	})
@@ -951,40 +3061,283 @@ func (c *Client) AddSourceFromURL(projectID string, url string) (string, error)
 		return "", fmt.Errorf("add source from URL: %w", err)
 	}
 
-	sourceID, err := extractSourceID(resp)
+	sourceID, err := c.addedSourceID(projectID, resp, "", url, before)
 	if err != nil {
 		return "", fmt.Errorf("extract source ID: %w", err)
 	}
 	return sourceID, nil
 }
 
+// pdfFetchTimeout bounds the download of a PDF linked by URL.
+const pdfFetchTimeout = 2 * time.Minute
+
+// pdfMagic starts every PDF file.
+var pdfMagic = []byte("%PDF-")
+
+// addPDFFromURL downloads the PDF at rawURL and uploads it as a file source
+// named after the last element of the URL path. The download goes through
+// the client's own HTTP client, so WithTransport, WithHTTPClient and replay
+// mode apply to it as they do to RPCs. Whether the body is a PDF is decided
+// by its leading bytes, since servers often label PDFs
+// application/octet-stream; if it is not, nothing is uploaded and isPDF is
+// false.
+func (c *Client) addPDFFromURL(projectID, rawURL string) (sourceID string, isPDF bool, err error) {
+	client := *c.rpc.HTTPClient()
+	if client.Timeout == 0 || client.Timeout > pdfFetchTimeout {
+		client.Timeout = pdfFetchTimeout
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", false, fmt.Errorf("fetch PDF: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("fetch PDF %s: %s", rawURL, resp.Status)
+	}
+	body := bufio.NewReader(resp.Body)
+	if head, _ := body.Peek(len(pdfMagic)); !bytes.Equal(head, pdfMagic) {
+		if c.rpc.Config.Debug {
+			fmt.Printf("%s is not a PDF (Content-Type %q), adding it as a web page\n", rawURL, resp.Header.Get("Content-Type"))
+		}
+		return "", false, nil
+	}
+	filename := "document.pdf"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := filepath.Base(u.Path); base != "." && base != "/" {
+			filename = base
+		}
+	}
+	sourceID, err = c.AddSourceFromReader(projectID, body, filename)
+	if err != nil {
+		return "", true, fmt.Errorf("add PDF from URL: %w", err)
+	}
+	return sourceID, true, nil
+}
+
+// addSourceWorkers bounds the number of sources added concurrently by
+// AddSourcesFromURLs and CreateProjectWithSources.
+const addSourceWorkers = 4
+
+// ErrSourceTimeout is reported in a SourceRef for a source that was not added
+// within the limits set by AddSourcesOptions.
+var ErrSourceTimeout = errors.New("source add timed out")
+
+// AddSourcesOptions bounds how long AddSourcesFromURLsWithOptions spends.
+// Zero values mean no limit.
+type AddSourcesOptions struct {
+	// ItemTimeout is the longest wait for any one source. A source that
+	// takes longer is reported with ErrSourceTimeout and the batch moves
+	// on; its request is abandoned, not cancelled, so the server may still
+	// add it. Abandoned requests keep their worker slot until they finish,
+	// so no more than a few requests are ever in flight; a source that
+	// cannot get a slot within ItemTimeout is reported without being sent.
+	ItemTimeout time.Duration
+	// Timeout is the deadline for the whole batch. Sources not started by
+	// then are reported with ErrSourceTimeout without being sent.
+	Timeout time.Duration
+}
+
+// SourceRef is the outcome of adding one source with AddSourcesFromURLs or
+// CreateProjectWithSources.
+type SourceRef struct {
+	URL      string // the URL added, for URL sources
+	SourceID string // empty if Err is set
+	Err      error
+}
+
+// AddSourcesFromURLs adds each URL as a source, a few at a time. YouTube
+// links are added as video sources, as with AddSourceFromURL. The result has
+// one entry per URL, in input order; a URL that fails to ingest has its Err
+// set and does not stop the others. The returned error is only for problems
+// affecting the whole call, such as an invalid project ID.
+func (c *Client) AddSourcesFromURLs(projectID string, urls []string) ([]*SourceRef, error) {
+	return c.AddSourcesFromURLsWithOptions(projectID, urls, AddSourcesOptions{})
+}
+
+// AddSourcesFromURLsWithOptions is AddSourcesFromURLs with time limits, so
+// that a few slow URLs cannot hold up a large import. Sources that exceed a
+// limit are reported with ErrSourceTimeout in their SourceRef.
+func (c *Client) AddSourcesFromURLsWithOptions(projectID string, urls []string, opts AddSourcesOptions) ([]*SourceRef, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	refs := addConcurrently(len(urls), opts, func(i int) *SourceRef {
+		id, err := c.AddSourceFromURL(projectID, urls[i])
+		return &SourceRef{URL: urls[i], SourceID: id, Err: err}
+	})
+	for i, ref := range refs {
+		ref.URL = urls[i]
+	}
+	return refs, nil
+}
+
+// addConcurrently calls add for 0 through n-1 on up to addSourceWorkers
+// goroutines and returns the results in index order. Calls that exceed the
+// limits in opts yield a SourceRef holding only an ErrSourceTimeout error.
+// At most addSourceWorkers calls run at once, counting those abandoned
+// after a timeout.
+func addConcurrently(n int, opts AddSourcesOptions, add func(i int) *SourceRef) []*SourceRef {
+	slots := make(chan struct{}, addSourceWorkers)
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+	refs := make([]*SourceRef, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(addSourceWorkers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				limit := opts.ItemTimeout
+				if !deadline.IsZero() {
+					left := time.Until(deadline)
+					if left <= 0 {
+						refs[i] = &SourceRef{Err: fmt.Errorf("%w: batch deadline of %s passed before it was sent", ErrSourceTimeout, opts.Timeout)}
+						continue
+					}
+					if limit == 0 || left < limit {
+						limit = left
+					}
+				}
+				refs[i] = addWithin(slots, limit, func() *SourceRef { return add(i) })
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return refs
+}
+
+// addWithin returns the result of add, or an ErrSourceTimeout ref if it takes
+// longer than limit. A zero limit waits indefinitely. add runs only while
+// holding one of slots, which it keeps until it returns even if abandoned,
+// and the wait for a free slot counts against limit.
+func addWithin(slots chan struct{}, limit time.Duration, add func() *SourceRef) *SourceRef {
+	if limit <= 0 {
+		slots <- struct{}{}
+		defer func() { <-slots }()
+		return add()
+	}
+	timer := time.NewTimer(limit)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+	case <-timer.C:
+		return &SourceRef{Err: fmt.Errorf("%w: no request slot freed within %s; not sent", ErrSourceTimeout, limit)}
+	}
+	done := make(chan *SourceRef, 1)
+	go func() {
+		defer func() { <-slots }()
+		done <- add()
+	}()
+	select {
+	case ref := <-done:
+		return ref
+	case <-timer.C:
+		return &SourceRef{Err: fmt.Errorf("%w after %s", ErrSourceTimeout, limit)}
+	}
+}
+
+// SourceInput describes one source for CreateProjectWithSources. Set exactly
+// one of URL, FilePath or Text; Title names a Text source.
+type SourceInput struct {
+	URL      string
+	FilePath string
+	Text     string
+	Title    string
+}
+
+// String identifies the input in error messages.
+func (in SourceInput) String() string {
+	switch {
+	case in.URL != "":
+		return in.URL
+	case in.FilePath != "":
+		return in.FilePath
+	case in.Title != "":
+		return in.Title
+	}
+	return "text source"
+}
+
+// errEmptySourceInput is reported for a SourceInput with nothing set.
+var errEmptySourceInput = errors.New("source input has no URL, file or text")
+
+// CreateProjectWithSources creates a notebook and adds sources to it, a few
+// at a time. The result has one SourceRef per input, in order. If some
+// sources fail, the notebook is still returned, along with the refs and an
+// error naming each failed source; the notebook is not rolled back.
+func (c *Client) CreateProjectWithSources(title, emoji string, sources []SourceInput) (*Notebook, []*SourceRef, error) {
+	project, err := c.CreateProject(title, emoji)
+	if err != nil {
+		return nil, nil, err
+	}
+	projectID := project.GetProjectId()
+
+	refs := addConcurrently(len(sources), AddSourcesOptions{}, func(i int) *SourceRef {
+		in := sources[i]
+		ref := &SourceRef{URL: in.URL}
+		switch {
+		case in.URL != "":
+			ref.SourceID, ref.Err = c.AddSourceFromURL(projectID, in.URL)
+		case in.FilePath != "":
+			ref.SourceID, ref.Err = c.AddSourceFromFile(projectID, in.FilePath)
+		case in.Text != "":
+			ref.SourceID, ref.Err = c.AddSourceFromText(projectID, in.Text, in.Title)
+		default:
+			ref.Err = errEmptySourceInput
+		}
+		return ref
+	})
+	c.invalidateProjectCache()
+
+	var errs []error
+	for i, ref := range refs {
+		ref.URL = sources[i].URL
+		if ref.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sources[i], ref.Err))
+		}
+	}
+	if len(errs) > 0 {
+		return project, refs, fmt.Errorf("%d of %d sources failed for %s: %w", len(errs), len(sources), projectID, errors.Join(errs...))
+	}
+	return project, refs, nil
+}
+
 func (c *Client) AddYouTubeSource(projectID, videoID string) (string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
 	if c.rpc.Config.Debug {
 		fmt.Printf("=== AddYouTubeSource ===\n")
 		fmt.Printf("Project ID: %s\n", projectID)
 		fmt.Printf("Video ID: %s\n", videoID)
 	}
 
-	// Modified payload structure for YouTube
 	payload := []interface{}{
-		[]interface{}{
-			[]interface{}{
-				nil,                                     // content
-				nil,                                     // title
-				videoID,                                 // video ID (not in array)
-				nil,                                     // unused
-				pb.SourceType_SOURCE_TYPE_YOUTUBE_VIDEO, // source type
-			},
-		},
+		[]interface{}{YouTubeSourceEntry(videoID)},
 		projectID,
 	}
 
+	// With WithSourceIDRecovery, note which sources exist before adding so
+	// that recovery from an empty response cannot pick up an earlier copy of
+	// the same video. If that fails the add goes ahead, only without
+	// recovery.
+	before, snapErr := c.recoverySnapshot(projectID)
+
 	if c.rpc.Config.Debug {
-		fmt.Printf("\nPayload Structure:\n")
-		spew.Dump(payload)
+		payloadJSON, _ := json.MarshalIndent(payload, "", "  ")
+		fmt.Printf("\nPayload Structure:\n%s\n", payloadJSON)
 	}
 
-	resp, err := c.rpc.Do(rpc.Call{
+	resp, err := c.doAddSources(rpc.Call{
 		ID:         rpc.RPCAddSources,
 		NotebookID: projectID,
 		Args:       payload,
@@ -997,108 +3350,311 @@ func (c *Client) AddYouTubeSource(projectID, videoID string) (string, error) {
 		fmt.Printf("\nRaw Response:\n%s\n", string(resp))
 	}
 
-	if len(resp) == 0 {
+	// The server sometimes answers a successful add with no payload. The
+	// source is there nonetheless, so look it up by video ID: the newest
+	// source for the video, or without a snapshot, the newest one overall.
+	if batchexecute.IsEmptyData(resp) {
+		if snapErr != nil {
+			return "", fmt.Errorf("add YouTube source: empty response, and recovery failed: %w", snapErr)
+		}
+		project, err := c.GetProject(projectID)
+		if err != nil {
+			return "", fmt.Errorf("add YouTube source: empty response, and recovery failed: %w", err)
+		}
+		if sourceID := findYouTubeSource(project, videoID, before); sourceID != "" {
+			return sourceID, nil
+		}
 		return "", fmt.Errorf("empty response from server (check debug output for request details)")
 	}
 
-	sourceID, err := extractSourceID(resp)
+	sourceID, err := c.extractSourceID(resp)
 	if err != nil {
 		return "", fmt.Errorf("extract source ID: %w", err)
 	}
 	return sourceID, nil
 }
 
-// Helper function to extract source ID with better error handling
-func extractSourceID(resp json.RawMessage) (string, error) {
+// findYouTubeSource returns the ID of the most recently modified source in
+// project for the given YouTube video, or "" if there is none. Sources in
+// skip are ignored.
+func findYouTubeSource(project *Notebook, videoID string, skip map[string]bool) string {
+	var (
+		id     string
+		newest time.Time
+	)
+	for _, src := range project.GetSources() {
+		if src.GetMetadata().GetYoutube().GetVideoId() != videoID || skip[src.GetSourceId().GetSourceId()] {
+			continue
+		}
+		modified := src.GetMetadata().GetLastModifiedTime().AsTime()
+		if id == "" || modified.After(newest) {
+			id = src.GetSourceId().GetSourceId()
+			newest = modified
+		}
+	}
+	return id
+}
+
+// sourceIDDepths lists the known AddSources response formats by the depth,
+// following leading elements, at which the source ID string sits:
+//
+//	Format 1: [[[["id",...]]]]
+//	Format 2: [[["id",...]]]
+//	Format 3: [["id",...]]
+//	Format 4: [[[[["id"],"title",...]]]] (a full Source entry)
+//
+// A string is found at exactly one depth, so at most one format matches.
+var sourceIDDepths = []int{3, 2, 1, 4}
+
+// extractSourceID returns the source ID in an AddSources response, logging
+// the matched format in debug mode.
+func (c *Client) extractSourceID(resp json.RawMessage) (string, error) {
+	id, format, err := sourceIDFromResponse(resp)
+	if c.rpc.Config.Debug {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Source ID extraction failed: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Source ID %s found using format %d\n", id, format)
+		}
+	}
+	return id, err
+}
+
+// recoverySnapshot returns the IDs of the project's sources if
+// WithSourceIDRecovery is set, for addedSourceID to tell new sources from
+// old ones, and nil otherwise.
+func (c *Client) recoverySnapshot(projectID string) (map[string]bool, error) {
+	if !c.recoverSourceIDs {
+		return nil, nil
+	}
+	return c.sourceIDs(projectID)
+}
+
+// sourceIDs returns the set of IDs of the project's sources.
+func (c *Client) sourceIDs(projectID string) (map[string]bool, error) {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("list existing sources: %w", err)
+	}
+	ids := make(map[string]bool, len(project.GetSources()))
+	for _, src := range project.GetSources() {
+		ids[src.GetSourceId().GetSourceId()] = true
+	}
+	return ids, nil
+}
+
+// addedSourceID returns the source ID in an AddSources response. If there
+// is none and WithSourceIDRecovery is set, it waits for the source to show
+// up in the project instead: the newest web page source stored with url,
+// or, if url is empty, the newest source titled title, ignoring the sources
+// in before, which existed ahead of the add.
+func (c *Client) addedSourceID(projectID string, resp json.RawMessage, title, url string, before map[string]bool) (string, error) {
+	id, err := c.extractSourceID(resp)
+	if err == nil || !c.recoverSourceIDs {
+		return id, err
+	}
+	var found string
+	_, perr := poll(c.recoveryTimeout, c.recoveryPoll, DefaultSourcePollOptions, func() (bool, error) {
+		var ferr error
+		found, ferr = c.findAddedSource(projectID, title, url, before)
+		return found != "", ferr
+	})
+	if perr != nil {
+		return "", fmt.Errorf("%w (recovery failed: %v)", err, perr)
+	}
+	if found == "" && url != "" {
+		return "", fmt.Errorf("%w (no source for %s to recover)", err, url)
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w (no source titled %q to recover)", err, title)
+	}
+	if c.rpc.Config.Debug {
+		fmt.Fprintf(os.Stderr, "Recovered source ID %s for %q %q\n", found, title, url)
+	}
+	return found, nil
+}
+
+// findAddedSource returns the ID of the newest source in the project
+// stored with url, or titled title if url is empty, or "" if there is none.
+// Sources in skip are ignored.
+func (c *Client) findAddedSource(projectID, title, url string, skip map[string]bool) (string, error) {
+	if url == "" {
+		project, err := c.GetProject(projectID)
+		if err != nil {
+			return "", err
+		}
+		return newestSourceTitled(project, title, skip), nil
+	}
+	sources, err := c.rawSources(projectID)
+	if err != nil {
+		return "", err
+	}
+	return newestSourceWithURL(sources, url, skip), nil
+}
+
+// newestSourceWithURL returns the ID of the most recently modified raw web
+// page source stored with url, or "" if there is none. Sources in skip are
+// ignored.
+func newestSourceWithURL(sources []interface{}, url string, skip map[string]bool) string {
+	var (
+		id     string
+		newest time.Time
+	)
+	for _, s := range sources {
+		src, ok := s.([]interface{})
+		if !ok || len(src) == 0 || rawSourceURL(src) != url || skip[firstString(src[0])] {
+			continue
+		}
+		modified := rawSourceModified(src)
+		if id == "" || modified.After(newest) {
+			id = firstString(src[0])
+			newest = modified
+		}
+	}
+	return id
+}
+
+// newestSourceTitled returns the ID of the most recently modified source in
+// project with the given title, or "" if there is none. Sources in skip are
+// ignored.
+func newestSourceTitled(project *Notebook, title string, skip map[string]bool) string {
+	var (
+		id     string
+		newest time.Time
+	)
+	for _, src := range project.GetSources() {
+		if src.GetTitle() != title || skip[src.GetSourceId().GetSourceId()] {
+			continue
+		}
+		modified := timeOf(src.GetMetadata().GetLastModifiedTime())
+		if id == "" || modified.After(newest) {
+			id = src.GetSourceId().GetSourceId()
+			newest = modified
+		}
+	}
+	return id
+}
+
+// sourceIDFromResponse returns the source ID and the number of the format
+// in sourceIDDepths that matched. On failure the error describes the shape
+// of the response along its leading elements, e.g. "array(1) > array(0)".
+func sourceIDFromResponse(resp json.RawMessage) (string, int, error) {
 	if len(resp) == 0 {
-		return "", fmt.Errorf("empty response")
+		return "", 0, fmt.Errorf("empty response")
 	}
 
 	var data []interface{}
 	if err := json.Unmarshal(resp, &data); err != nil {
-		return "", fmt.Errorf("parse response JSON: %w", err)
+		return "", 0, fmt.Errorf("parse response JSON: %w", err)
 	}
 
-	// Try different response formats
-	// Format 1: [[[["id",...]]]]
-	// Format 2: [[["id",...]]]
-	// Format 3: [["id",...]]
-	for _, format := range []func([]interface{}) (string, bool){
-		// Format 1
-		func(d []interface{}) (string, bool) {
-			if len(d) > 0 {
-				if d0, ok := d[0].([]interface{}); ok && len(d0) > 0 {
-					if d1, ok := d0[0].([]interface{}); ok && len(d1) > 0 {
-						if d2, ok := d1[0].([]interface{}); ok && len(d2) > 0 {
-							if id, ok := d2[0].(string); ok {
-								return id, true
-							}
-						}
-					}
-				}
-			}
-			return "", false
-		},
-		// Format 2
-		func(d []interface{}) (string, bool) {
-			if len(d) > 0 {
-				if d0, ok := d[0].([]interface{}); ok && len(d0) > 0 {
-					if d1, ok := d0[0].([]interface{}); ok && len(d1) > 0 {
-						if id, ok := d1[0].(string); ok {
-							return id, true
-						}
-					}
-				}
+	for i, depth := range sourceIDDepths {
+		var v interface{} = data
+		for d := 0; d < depth; d++ {
+			arr, ok := v.([]interface{})
+			if !ok || len(arr) == 0 {
+				v = nil
+				break
 			}
-			return "", false
-		},
-		// Format 3
-		func(d []interface{}) (string, bool) {
-			if len(d) > 0 {
-				if d0, ok := d[0].([]interface{}); ok && len(d0) > 0 {
-					if id, ok := d0[0].(string); ok {
-						return id, true
-					}
-				}
+			v = arr[0]
+		}
+		if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
+			if id, ok := arr[0].(string); ok {
+				return id, i + 1, nil
 			}
-			return "", false
-		},
-	} {
-		if id, ok := format(data); ok {
-			return id, nil
 		}
 	}
 
-	return "", fmt.Errorf("could not find source ID in response structure: %v", data)
+	return "", 0, fmt.Errorf("could not find source ID in response structure %s", leadingShape(data))
+}
+
+// leadingShape describes v by descending into the first element of nested
+// arrays, which is where every known format keeps the source ID.
+func leadingShape(v interface{}) string {
+	var parts []string
+	for {
+		switch t := v.(type) {
+		case []interface{}:
+			parts = append(parts, fmt.Sprintf("array(%d)", len(t)))
+			if len(t) == 0 {
+				return strings.Join(parts, " > ")
+			}
+			v = t[0]
+			continue
+		case string:
+			parts = append(parts, "string")
+		case float64:
+			parts = append(parts, "number")
+		case bool:
+			parts = append(parts, "bool")
+		case nil:
+			parts = append(parts, "null")
+		default:
+			parts = append(parts, "object")
+		}
+		return strings.Join(parts, " > ")
+	}
 }
 
 // Note operations
 
+// NoteType is the kind of a note, which determines how the web UI labels
+// and renders it.
+type NoteType int
+
+// Note types, as sent in the CreateNote payload. Only NoteTypeUser, the
+// value the original client always sent, is confirmed. The others are
+// unverified guesses at the UI's saved-response and study-guide kinds; no
+// captured request or response backs them, and the CLI never sends them.
+const (
+	// NoteTypeUser is a note written by the user.
+	NoteTypeUser NoteType = 1
+	// NoteTypeSavedResponse is a chat response saved to a note. Unverified.
+	NoteTypeSavedResponse NoteType = 2
+	// NoteTypeStudyGuide is a generated note such as a study guide or
+	// briefing doc. Unverified.
+	NoteTypeStudyGuide NoteType = 3
+)
+
+// CreateNote creates a user note. It is CreateNoteWithType with
+// NoteTypeUser.
 func (c *Client) CreateNote(projectID string, title string, initialContent string) (*Note, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	return c.CreateNoteWithType(projectID, title, initialContent, NoteTypeUser)
+}
+
+// CreateNoteWithType creates a note of the given type.
+func (c *Client) CreateNoteWithType(projectID string, title string, initialContent string, noteType NoteType) (*Note, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var note Note
+	if err := doProto(c, rpc.Call{
 		ID: rpc.RPCCreateNote,
 		Args: []interface{}{
 			projectID,
 			initialContent,
-			[]int{1}, // note type
+			[]int{int(noteType)},
 			nil,
 			title,
 		},
 		NotebookID: projectID,
-	})
-	if err != nil {
+	}, &note); err != nil {
 		return nil, fmt.Errorf("create note: %w", err)
 	}
-
-	var note Note
-	if err := beprotojson.Unmarshal(resp, &note); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
-	}
 	return &note, nil
 }
 
+// MutateNote replaces the content and title of a note. It is idempotent and
+// safe to retry; AppendToNote, which builds on it, is not (see
+// AppendToNoteWithRetry).
 func (c *Client) MutateNote(projectID string, noteID string, content string, title string) (*Note, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var note Note
+	if err := doProto(c, rpc.Call{
 		ID: rpc.RPCMutateNote,
 		Args: []interface{}{
 			projectID,
@@ -1108,20 +3664,18 @@ func (c *Client) MutateNote(projectID string, noteID string, content string, tit
 			}},
 		},
 		NotebookID: projectID,
-	})
-	if err != nil {
+	}, &note); err != nil {
 		return nil, fmt.Errorf("mutate note: %w", err)
 	}
-
-	var note Note
-	if err := beprotojson.Unmarshal(resp, &note); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
-	}
 	return &note, nil
 }
 
 func (c *Client) DeleteNotes(projectID string, noteIDs []string) error {
-	_, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	_, err = c.rpc.Do(rpc.Call{
 		ID: rpc.RPCDeleteNotes,
 		Args: []interface{}{
 			[][][]string{{noteIDs}},
@@ -1132,6 +3686,146 @@ func (c *Client) DeleteNotes(projectID string, noteIDs []string) error {
 }
 
 func (c *Client) GetNotes(projectID string) ([]*Note, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var response pb.GetNotesResponse
+	if err := doProto(c, rpc.Call{
+		ID:         rpc.RPCGetNotes,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	}, &response); err != nil {
+		return nil, fmt.Errorf("get notes: %w", err)
+	}
+	return response.Notes, nil
+}
+
+// ErrNoteNotFound is returned by GetNoteByTitle when no note has the title.
+var ErrNoteNotFound = errors.New("note not found")
+
+// ErrAmbiguousNoteTitle is returned by GetNoteByTitle when several notes
+// share the title. The error lists their IDs so one can be picked.
+var ErrAmbiguousNoteTitle = errors.New("several notes have this title")
+
+// GetNoteByTitle returns the note with exactly the given title.
+func (c *Client) GetNoteByTitle(projectID, title string) (*Note, error) {
+	notes, err := c.GetNotes(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Note
+	for _, n := range notes {
+		if n.GetTitle() == title {
+			matches = append(matches, n)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%q: %w", title, ErrNoteNotFound)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, n := range matches {
+		ids[i] = n.GetSourceId().GetSourceId()
+	}
+	return nil, fmt.Errorf("%q: %w: %s", title, ErrAmbiguousNoteTitle, strings.Join(ids, ", "))
+}
+
+// DeleteNoteByTitle deletes the note with exactly the given title. Nothing
+// is deleted if no note or more than one note has it; see GetNoteByTitle.
+func (c *Client) DeleteNoteByTitle(projectID, title string) error {
+	note, err := c.GetNoteByTitle(projectID, title)
+	if err != nil {
+		return fmt.Errorf("delete note: %w", err)
+	}
+	return c.DeleteNotes(projectID, []string{note.GetSourceId().GetSourceId()})
+}
+
+// NoteCitations returns the IDs of the sources cited by a note, in the order
+// they first appear. The Note proto does not model citations, so a *Note
+// cannot answer this; they are recovered from the raw GetNotes entry by
+// noteCitations. A note without citations yields an empty slice.
+func (c *Client) NoteCitations(projectID, noteID string) ([]string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("note citations: %w", err)
+	}
+	noteArr, err := c.rawNote(projectID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	return noteCitations(noteArr, project), nil
+}
+
+// noteCitations returns the IDs of project's sources mentioned anywhere in
+// noteArr, a raw GetNotes entry, in the order they first appear. The
+// leading note ID is skipped so that it cannot match a source.
+func noteCitations(noteArr []interface{}, project *Notebook) []string {
+	if len(noteArr) < 2 {
+		return []string{}
+	}
+	sourceIDs := make(map[string]bool, len(project.GetSources()))
+	for _, src := range project.GetSources() {
+		sourceIDs[src.GetSourceId().GetSourceId()] = true
+	}
+	return citedSourceIDs(noteArr[1:], sourceIDs)
+}
+
+// SourceReferences returns the notes that cite a source, in the order
+// GetNotes lists them, answering "what have I written based on this
+// document". Only notes are searched; chat history is not available through
+// any known RPC. The notes carry just their ID and title, as recovered from
+// the raw GetNotes response. A source cited by no note yields an empty slice.
+func (c *Client) SourceReferences(projectID, sourceID string) ([]*Note, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := c.rawNotes(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("source references: %w", err)
+	}
+	cited := map[string]bool{sourceID: true}
+	refs := []*Note{}
+	for _, n := range notes {
+		noteArr, ok := n.([]interface{})
+		if !ok || len(noteArr) < 2 || len(citedSourceIDs(noteArr[1:], cited)) == 0 {
+			continue
+		}
+		title, _ := rawNoteTitle(noteArr)
+		refs = append(refs, &Note{
+			SourceId: &pb.SourceId{SourceId: firstString(noteArr[0])},
+			Title:    title,
+		})
+	}
+	return refs, nil
+}
+
+// rawNote returns the undecoded GetNotes entry for noteID. Entries have the
+// form [noteID, [noteID, content, [type], citations, title], ...]; see
+// rawNoteTitle.
+func (c *Client) rawNote(projectID, noteID string) ([]interface{}, error) {
+	notes, err := c.rawNotes(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notes {
+		noteArr, ok := n.([]interface{})
+		if ok && len(noteArr) > 0 && firstString(noteArr[0]) == noteID {
+			return noteArr, nil
+		}
+	}
+	return nil, fmt.Errorf("note %s not found in project %s", noteID, projectID)
+}
+
+// rawNotes returns the undecoded entries of a GetNotes response.
+func (c *Client) rawNotes(projectID string) ([]interface{}, error) {
 	resp, err := c.rpc.Do(rpc.Call{
 		ID:         rpc.RPCGetNotes,
 		Args:       []interface{}{projectID},
@@ -1140,33 +3834,252 @@ func (c *Client) GetNotes(projectID string) ([]*Note, error) {
 	if err != nil {
 		return nil, fmt.Errorf("get notes: %w", err)
 	}
+	var data []interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, fmt.Errorf("parse response JSON: %w", err)
+	}
 
-	var response pb.GetNotesResponse
-	if err := beprotojson.Unmarshal(resp, &response); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	var notes []interface{}
+	if len(data) > 0 {
+		notes, _ = data[0].([]interface{})
+	}
+	return notes, nil
+}
+
+// rawNoteField returns the string at position i of a raw note's body.
+func rawNoteField(noteArr []interface{}, i int) string {
+	if len(noteArr) < 2 {
+		return ""
+	}
+	body, _ := noteArr[1].([]interface{})
+	if len(body) <= i {
+		return ""
+	}
+	s, _ := body[i].(string)
+	return s
+}
+
+// rawNoteTitleSlot is the position of the title in a raw note's body. It
+// follows the argument order of CreateNote (content, type, nil, title); no
+// captured GetNotes response confirms it.
+const rawNoteTitleSlot = 4
+
+// rawNoteTitle returns the title of a raw note, and false if its body is too
+// short to have one.
+func rawNoteTitle(noteArr []interface{}) (string, bool) {
+	if len(noteArr) < 2 {
+		return "", false
+	}
+	body, _ := noteArr[1].([]interface{})
+	if len(body) <= rawNoteTitleSlot {
+		return "", false
+	}
+	s, ok := body[rawNoteTitleSlot].(string)
+	return s, ok
+}
+
+// AppendToNote adds text to the end of a note's content, separated by a
+// newline. No append RPC is known, so this reads the current content and
+// writes back the concatenation with MutateNote. It is not atomic: a write
+// from another client between the read and the write is lost. It is not
+// idempotent either: calling it again after a failed call whose write did
+// reach the server appends text twice. Use AppendToNoteWithRetry to retry.
+// MutateNote replaces the title too, so the current one is written back; a
+// note whose title cannot be read is left untouched and reported.
+func (c *Client) AppendToNote(projectID, noteID, text string) (*Note, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	noteArr, err := c.rawNote(projectID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("append to note: %w", err)
+	}
+	title, ok := rawNoteTitle(noteArr)
+	if !ok {
+		return nil, fmt.Errorf("append to note: cannot read the title of note %s", noteID)
+	}
+	content := appendNoteText(rawNoteField(noteArr, 1), text)
+	return c.MutateNote(projectID, noteID, content, title)
+}
+
+// AppendToNoteWithRetry is AppendToNote with up to attempts tries. The
+// content to write is computed once, and after a failed write the note is
+// re-read: if it already holds the new content, the earlier write took effect
+// (e.g. the call timed out after the server applied it) and is not repeated.
+// If the note was changed by someone else in the meantime, it gives up
+// rather than overwrite that change. The title is kept as AppendToNote
+// keeps it.
+func (c *Client) AppendToNoteWithRetry(projectID, noteID, text string, attempts int) (*Note, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	noteArr, err := c.rawNote(projectID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("append to note: %w", err)
+	}
+	title, ok := rawNoteTitle(noteArr)
+	if !ok {
+		return nil, fmt.Errorf("append to note: cannot read the title of note %s", noteID)
+	}
+	base := rawNoteField(noteArr, 1)
+	want := appendNoteText(base, text)
+
+	for attempt := 1; ; attempt++ {
+		note, err := c.MutateNote(projectID, noteID, want, title)
+		if err == nil {
+			return note, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("append to note: %w", err)
+		}
+		noteArr, rerr := c.rawNote(projectID, noteID)
+		if rerr != nil {
+			return nil, fmt.Errorf("append to note: %w (confirming write: %v)", err, rerr)
+		}
+		switch rawNoteField(noteArr, 1) {
+		case want:
+			return &Note{SourceId: &pb.SourceId{SourceId: noteID}}, nil
+		case base:
+			if c.rpc.Config.Debug {
+				fmt.Printf("Append to note %s failed, retrying (attempt %d/%d): %v\n", noteID, attempt+1, attempts, err)
+			}
+		default:
+			return nil, fmt.Errorf("append to note: note %s changed while retrying: %w", noteID, err)
+		}
+	}
+}
+
+// appendNoteText returns content with text appended on a new line.
+func appendNoteText(content, text string) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + text
+}
+
+// TODO: converting a note to a source is not supported yet. The RPC behind
+// the web UI's "Convert to source" action has not been captured, and adding
+// the note's text with AddSourceFromText is no substitute: the source would
+// be listed as pasted text rather than as a note
+// (pb.SourceType_SOURCE_TYPE_SHARED_NOTE). Once it is,
+// ConvertNoteToSource(projectID, noteID) should wrap it and return the new
+// source ID.
+
+// citedSourceIDs collects, in order and without duplicates, every string in
+// v that names one of the given sources.
+func citedSourceIDs(v []interface{}, sourceIDs map[string]bool) []string {
+	cited := []string{}
+	seen := make(map[string]bool)
+	walkStrings(v, func(s string) {
+		if sourceIDs[s] && !seen[s] {
+			seen[s] = true
+			cited = append(cited, s)
+		}
+	})
+	return cited
+}
+
+// walkStrings calls fn for every string nested anywhere in v.
+func walkStrings(v interface{}, fn func(string)) {
+	switch v := v.(type) {
+	case string:
+		fn(v)
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	}
+}
+
+// firstString returns the first string found by descending into the leading
+// element of nested arrays, e.g. "id" for [["id"]].
+func firstString(v interface{}) string {
+	for {
+		switch t := v.(type) {
+		case string:
+			return t
+		case []interface{}:
+			if len(t) == 0 {
+				return ""
+			}
+			v = t[0]
+		default:
+			return ""
+		}
 	}
-	return response.Notes, nil
 }
 
 // Audio operations
 
+// AudioOverviewOptions configures CreateAudioOverviewWithOptions.
+type AudioOverviewOptions struct {
+	// Instructions direct the hosts' focus and persona.
+	Instructions string
+	// FocusSources restricts the overview to the given source IDs. When
+	// empty, all enabled sources in the notebook are used. The source slot
+	// is a guess that has not been checked against the web client, so the
+	// server may still use every source; see audioOverviewArgs.
+	FocusSources []string
+	// Language is the BCP-47 code of the spoken language (e.g. "ja"). When
+	// empty, the account's default language is used. Unverified; see
+	// audioOverviewArgs.
+	Language string
+	// Format selects the number of hosts. The zero value is the default
+	// two-host conversation. Unverified; see audioOverviewArgs.
+	Format AudioFormat
+	// Interactive enables interactive mode, where the listener can join the
+	// conversation and ask the hosts questions. Unverified; see
+	// audioOverviewArgs.
+	Interactive bool
+}
+
+// AudioFormat is the host configuration of an audio overview, sent in the
+// second CreateAudioOverview slot. Only AudioFormatTwoHosts, the 0 the
+// original client always sent, is known to work; AudioFormatSingleHost is a
+// guess.
+type AudioFormat int
+
+const (
+	AudioFormatTwoHosts   AudioFormat = 0
+	AudioFormatSingleHost AudioFormat = 1
+)
+
+// ErrSourceNotInProject is returned when a source ID passed to a
+// notebook-scoped operation does not belong to that notebook.
+var ErrSourceNotInProject = errors.New("source not in project")
+
 func (c *Client) CreateAudioOverview(projectID string, instructions string) (*AudioOverviewResult, error) {
-	if projectID == "" {
-		return nil, fmt.Errorf("project ID required")
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateAudioOverviewWithOptions(projectID, AudioOverviewOptions{
+		Instructions: instructions,
+	})
+}
+
+// CreateAudioOverviewWithOptions starts generating an audio overview. If
+// opts.FocusSources is set, the IDs are checked against the project's sources
+// before the request is sent. See audioOverviewArgs for the payload layout.
+func (c *Client) CreateAudioOverviewWithOptions(projectID string, opts AudioOverviewOptions) (*AudioOverviewResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
 	}
-	if instructions == "" {
+	if opts.Instructions == "" {
 		return nil, fmt.Errorf("instructions required")
 	}
+	if len(opts.FocusSources) > 0 {
+		if err := c.validateProjectSources(projectID, opts.FocusSources); err != nil {
+			return nil, fmt.Errorf("create audio overview: %w", err)
+		}
+	}
 
 	resp, err := c.rpc.Do(rpc.Call{
-		ID: rpc.RPCCreateAudioOverview,
-		Args: []interface{}{
-			projectID,
-			0,
-			[]string{
-				instructions,
-			},
-		},
+		ID:         rpc.RPCCreateAudioOverview,
+		Args:       audioOverviewArgs(projectID, opts),
 		NotebookID: projectID,
 	})
 	if err != nil {
@@ -1217,12 +4130,80 @@ func (c *Client) CreateAudioOverview(projectID string, instructions string) (*Au
 				result.IsReady = ready
 			}
 		}
+
+		result.Format = audioFormat(audioData, result.AudioData)
 	}
 
 	return result, nil
 }
 
+// audioOverviewArgs builds the CreateAudioOverview payload:
+//
+//	[projectID, format, [instructions], [[sourceID]...], language, [interactive]]
+//
+// Only the first three slots are confirmed, by the original client. The
+// others, and any format other than 0, were inferred from other RPCs and
+// have not been checked against a captured web client request, so the
+// server may ignore or reject them; the CLI does not set them. Trailing
+// unset slots are dropped, so options left at their zero values produce the
+// original three-element request.
+func audioOverviewArgs(projectID string, opts AudioOverviewOptions) []interface{} {
+	args := []interface{}{
+		projectID,
+		int(opts.Format),
+		[]string{
+			opts.Instructions,
+		},
+		nil,
+		nil,
+		nil,
+	}
+	if len(opts.FocusSources) > 0 {
+		sources := make([][]string, len(opts.FocusSources))
+		for i, id := range opts.FocusSources {
+			sources[i] = []string{id}
+		}
+		args[3] = sources
+	}
+	if opts.Language != "" {
+		args[4] = opts.Language
+	}
+	if opts.Interactive {
+		args[5] = []bool{true}
+	}
+	for len(args) > 3 && args[len(args)-1] == nil {
+		args = args[:len(args)-1]
+	}
+	return args
+}
+
+// validateProjectSources checks that every ID in sourceIDs is a source of
+// the given project.
+func (c *Client) validateProjectSources(projectID string, sourceIDs []string) error {
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(project.Sources))
+	for _, src := range project.Sources {
+		known[src.GetSourceId().GetSourceId()] = true
+	}
+	for _, id := range sourceIDs {
+		if !known[id] {
+			return fmt.Errorf("%w: %s", ErrSourceNotInProject, id)
+		}
+	}
+	return nil
+}
+
+// GetAudioOverview returns the notebook's audio overview, or nil and no
+// error if it has none. NotebookLM keeps at most one audio overview per
+// notebook; creating a new one replaces it, so there is no history to list.
 func (c *Client) GetAudioOverview(projectID string) (*AudioOverviewResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := c.rpc.Do(rpc.Call{
 		ID: rpc.RPCGetAudioOverview,
 		Args: []interface{}{
@@ -1234,62 +4215,214 @@ func (c *Client) GetAudioOverview(projectID string) (*AudioOverviewResult, error
 	if err != nil {
 		return nil, fmt.Errorf("get audio overview: %w", err)
 	}
+	if len(bytes.TrimSpace(resp)) == 0 {
+		return nil, nil
+	}
 
 	var data []interface{}
 	if err := json.Unmarshal(resp, &data); err != nil {
 		return nil, fmt.Errorf("parse response JSON: %w", err)
 	}
 
-	result := &AudioOverviewResult{
-		ProjectID: projectID,
+	// A notebook without an audio overview has no audio slot.
+	if len(data) < 3 || data[2] == nil {
+		return nil, nil
 	}
 
-	// Handle empty or nil response
-	if len(data) == 0 {
-		return result, nil
+	result := &AudioOverviewResult{
+		ProjectID: projectID,
 	}
 
 	// Parse the wrb.fr response format
 	// Format: [null,null,[3,"<base64-audio>","<id>","<title>",null,true],null,[false]]
-	if len(data) > 2 {
+	{
 		audioData, ok := data[2].([]interface{})
 		if !ok || len(audioData) < 4 {
 			return nil, fmt.Errorf("invalid audio data format")
 		}
 
-		// Extract audio data (index 1)
-		if audioBase64, ok := audioData[1].(string); ok {
-			result.AudioData = audioBase64
-		}
+		// Extract audio data (index 1)
+		if audioBase64, ok := audioData[1].(string); ok {
+			result.AudioData = audioBase64
+		}
+
+		// Extract ID (index 2)
+		if id, ok := audioData[2].(string); ok {
+			result.AudioID = id
+		}
+
+		// Extract title (index 3)
+		if title, ok := audioData[3].(string); ok {
+			result.Title = title
+		}
+
+		// Extract ready status (index 5)
+		if len(audioData) > 5 {
+			if ready, ok := audioData[5].(bool); ok {
+				result.IsReady = ready
+			}
+		}
+
+		result.Format = audioFormat(audioData, result.AudioData)
+	}
+
+	return result, nil
+}
+
+// ErrAudioNotReady is returned by WaitForAudioOverview when the overview is
+// still generating after the timeout elapses.
+var ErrAudioNotReady = errors.New("audio overview not ready")
+
+// WaitForAudioOverview polls GetAudioOverview until the overview is ready or
+// timeout elapses. A nil opts uses DefaultAudioPollOptions.
+func (c *Client) WaitForAudioOverview(projectID string, timeout time.Duration, opts *PollOptions) (*AudioOverviewResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var result *AudioOverviewResult
+	done, err := poll(timeout, opts, DefaultAudioPollOptions, func() (bool, error) {
+		var err error
+		result, err = c.GetAudioOverview(projectID)
+		if err != nil {
+			return false, fmt.Errorf("wait for audio overview: %w", err)
+		}
+		return result != nil && result.IsReady, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return result, fmt.Errorf("audio overview for %s after %s: %w", projectID, timeout, ErrAudioNotReady)
+	}
+	return result, nil
+}
+
+// StartAudioOverview starts generating an audio overview, as
+// CreateAudioOverviewWithOptions does, and returns a handle on the
+// generation. Cancelling it behaves as CancelAudioOverview.
+func (c *Client) StartAudioOverview(projectID string, opts AudioOverviewOptions) (*Operation, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.CreateAudioOverviewWithOptions(projectID, opts); err != nil {
+		return nil, err
+	}
+	return &Operation{
+		status: func() (OperationState, error) {
+			result, err := c.GetAudioOverview(projectID)
+			if err != nil {
+				return OperationRunning, fmt.Errorf("audio overview status: %w", err)
+			}
+			if result != nil && result.IsReady {
+				return OperationDone, nil
+			}
+			return OperationRunning, nil
+		},
+		cancel: func() error {
+			return c.CancelAudioOverview(projectID)
+		},
+		poll: DefaultAudioPollOptions,
+	}, nil
+}
+
+// AudioOverviewResult represents an audio overview response
+type AudioOverviewResult struct {
+	ProjectID string `json:"project_id"`
+	AudioID   string `json:"audio_id"`
+	Title     string `json:"title"`
+	AudioData string `json:"audio_data,omitempty"` // Base64 encoded audio data
+	Format    string `json:"format,omitempty"`     // MIME type, e.g. "audio/wav"
+	IsReady   bool   `json:"is_ready"`
+}
+
+// audioExtensions maps the audio MIME types NotebookLM is known to produce
+// to file extensions.
+var audioExtensions = map[string]string{
+	"audio/wav":   ".wav",
+	"audio/wave":  ".wav",
+	"audio/x-wav": ".wav",
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/mp4":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"audio/ogg":   ".ogg",
+}
 
-		// Extract ID (index 2)
-		if id, ok := audioData[2].(string); ok {
-			result.AudioID = id
-		}
+// extForAudioMIME returns the file extension, with the leading dot, for an
+// audio MIME type. Parameters such as "; codecs=opus" are ignored. Unknown
+// types yield ".wav", which the web client used before the format was
+// reported.
+func extForAudioMIME(mimeType string) string {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	if ext, ok := audioExtensions[strings.ToLower(strings.TrimSpace(mimeType))]; ok {
+		return ext
+	}
+	return ".wav"
+}
 
-		// Extract title (index 3)
-		if title, ok := audioData[3].(string); ok {
-			result.Title = title
-		}
+// FileExtension returns the file extension for the audio Format, including
+// the leading dot.
+func (r *AudioOverviewResult) FileExtension() string {
+	return extForAudioMIME(r.Format)
+}
 
-		// Extract ready status (index 5)
-		if len(audioData) > 5 {
-			if ready, ok := audioData[5].(bool); ok {
-				result.IsReady = ready
-			}
+// SaveToFile writes the decoded audio to path and returns the name written.
+// If path has no extension, the one matching Format is appended; an
+// extension the caller chose is kept as is.
+func (r *AudioOverviewResult) SaveToFile(path string) (string, error) {
+	data, err := r.GetAudioBytes()
+	if err != nil {
+		return "", fmt.Errorf("decode audio data: %w", err)
+	}
+	if filepath.Ext(path) == "" {
+		path += r.FileExtension()
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("save audio file: %w", err)
+	}
+	return path, nil
+}
+
+// audioFormat returns the MIME type of an audio overview. A type reported
+// in the response array is preferred; otherwise the leading bytes of the
+// base64 audio are sniffed. It returns "" if neither is conclusive.
+func audioFormat(audioArr []interface{}, audioBase64 string) string {
+	var format string
+	walkStrings(audioArr, func(s string) {
+		if format == "" && strings.HasPrefix(s, "audio/") && len(s) < 64 {
+			format = s
 		}
+	})
+	if format != "" {
+		return format
 	}
 
-	return result, nil
+	// 16 base64 characters decode to the 12 bytes the magic numbers need.
+	if len(audioBase64) < 16 {
+		return ""
+	}
+	head, err := base64.StdEncoding.DecodeString(audioBase64[:16])
+	if err != nil {
+		return ""
+	}
+	return sniffAudioFormat(head)
 }
 
-// AudioOverviewResult represents an audio overview response
-type AudioOverviewResult struct {
-	ProjectID string
-	AudioID   string
-	Title     string
-	AudioData string // Base64 encoded audio data
-	IsReady   bool
+func sniffAudioFormat(b []byte) string {
+	switch {
+	case len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WAVE":
+		return "audio/wav"
+	case len(b) >= 8 && string(b[4:8]) == "ftyp":
+		return "audio/mp4"
+	case len(b) >= 4 && string(b[:4]) == "OggS":
+		return "audio/ogg"
+	case len(b) >= 3 && string(b[:3]) == "ID3",
+		len(b) >= 2 && b[0] == 0xFF && b[1]&0xE0 == 0xE0:
+		return "audio/mpeg"
+	}
+	return ""
 }
 
 // GetAudioBytes returns the decoded audio data
@@ -1300,8 +4433,22 @@ func (r *AudioOverviewResult) GetAudioBytes() ([]byte, error) {
 	return base64.StdEncoding.DecodeString(r.AudioData)
 }
 
+// Reader returns the decoded audio as a stream, for copying a large
+// overview to a file or upload without holding a second, decoded copy in
+// memory as GetAudioBytes does. Malformed base64 surfaces as a read error.
+func (r *AudioOverviewResult) Reader() (io.Reader, error) {
+	if r.AudioData == "" {
+		return nil, fmt.Errorf("no audio data available")
+	}
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(r.AudioData)), nil
+}
+
 func (c *Client) DeleteAudioOverview(projectID string) error {
-	_, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	_, err = c.rpc.Do(rpc.Call{
 		ID:         rpc.RPCDeleteAudioOverview,
 		Args:       []interface{}{projectID},
 		NotebookID: projectID,
@@ -1309,111 +4456,502 @@ func (c *Client) DeleteAudioOverview(projectID string) error {
 	return err
 }
 
+// ErrAudioAlreadyReady is returned by CancelAudioOverview when generation
+// has already finished.
+var ErrAudioAlreadyReady = errors.New("audio overview already generated")
+
+// ErrNoAudioOverview is returned by CancelAudioOverview when the notebook has
+// no audio overview, generating or otherwise, so there is nothing to cancel.
+var ErrNoAudioOverview = errors.New("no audio overview")
+
+// CancelAudioOverview stops an audio overview that is still generating. No
+// cancel RPC is known; deleting the overview while it is in progress aborts
+// generation, so that is what this does. To avoid discarding a finished
+// overview by accident, it returns ErrAudioAlreadyReady instead of deleting
+// one that is ready; use DeleteAudioOverview for that. A notebook without an
+// audio overview yields ErrNoAudioOverview.
+func (c *Client) CancelAudioOverview(projectID string) error {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	result, err := c.GetAudioOverview(projectID)
+	if err != nil {
+		return fmt.Errorf("cancel audio overview: %w", err)
+	}
+	if result == nil {
+		return fmt.Errorf("cancel audio overview %s: %w", projectID, ErrNoAudioOverview)
+	}
+	if result.IsReady {
+		return fmt.Errorf("cancel audio overview %s: %w", projectID, ErrAudioAlreadyReady)
+	}
+	if err := c.DeleteAudioOverview(projectID); err != nil {
+		return fmt.Errorf("cancel audio overview: %w", err)
+	}
+	return nil
+}
+
+// Chat operations
+//
+// TODO: chat is not implemented yet. The web client does not send questions
+// through batchexecute but through the separate GenerateFreeFormStreamed
+// endpoint, whose request and response shapes are still undocumented. Once
+// AskQuestion exists it should return a session carrying the conversation
+// ID from its first response, so that a FollowUpQuestion(session, question)
+// can continue the thread with the prior context. GenerateFreeFormStreamed
+// answers in growing fragments, so a streaming variant,
+// AskQuestionStream(ctx, projectID, question, onToken), should pass each new
+// suffix to onToken as it arrives and return the citations, which only come
+// with the final fragment, once the stream ends.
+
 // Generation operations
 
 func (c *Client) GenerateDocumentGuides(projectID string) (*pb.GenerateDocumentGuidesResponse, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var guides pb.GenerateDocumentGuidesResponse
+	if err := doProto(c, rpc.Call{
 		ID:         rpc.RPCGenerateDocumentGuides,
 		Args:       []interface{}{projectID},
 		NotebookID: projectID,
-	})
-	if err != nil {
+	}, &guides); err != nil {
 		return nil, fmt.Errorf("generate document guides: %w", err)
 	}
-
-	var guides pb.GenerateDocumentGuidesResponse
-	if err := beprotojson.Unmarshal(resp, &guides); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
-	}
 	return &guides, nil
 }
 
 func (c *Client) GenerateNotebookGuide(projectID string) (*pb.GenerateNotebookGuideResponse, error) {
-	resp, err := c.rpc.Do(rpc.Call{
-		ID:         rpc.RPCGenerateNotebookGuide,
-		Args:       []interface{}{projectID},
-		NotebookID: projectID,
-	})
+	return c.GenerateNotebookGuideForSources(projectID, nil)
+}
+
+// GenerateNotebookGuideForSources generates the notebook guide from only the
+// given sources, which are checked against the project first. With no
+// source IDs the whole notebook is used, as in GenerateNotebookGuide. The
+// restriction is unverified (see generationArgs), so the CLI does not offer
+// it.
+func (c *Client) GenerateNotebookGuideForSources(projectID string, sourceIDs []string) (*pb.GenerateNotebookGuideResponse, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	args, err := c.generationArgs(projectID, sourceIDs)
 	if err != nil {
 		return nil, fmt.Errorf("generate notebook guide: %w", err)
 	}
-
 	var guide pb.GenerateNotebookGuideResponse
-	if err := beprotojson.Unmarshal(resp, &guide); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := doProto(c, rpc.Call{
+		ID:         rpc.RPCGenerateNotebookGuide,
+		Args:       args,
+		NotebookID: projectID,
+	}, &guide); err != nil {
+		return nil, fmt.Errorf("generate notebook guide: %w", err)
 	}
 	return &guide, nil
 }
 
-func (c *Client) GenerateOutline(projectID string) (*pb.GenerateOutlineResponse, error) {
+// generationArgs builds the payload of a notebook-wide generation RPC,
+// [projectID] or, restricted to some sources, [projectID, [[sourceID]...]].
+// The source list takes the nested form CreateAudioOverview uses for its
+// focus sources. That form is itself unverified and no captured request
+// shows either RPC taking a second slot, so the server may ignore it and
+// cover the whole notebook.
+func (c *Client) generationArgs(projectID string, sourceIDs []string) ([]interface{}, error) {
+	if len(sourceIDs) == 0 {
+		return []interface{}{projectID}, nil
+	}
+	if err := c.validateProjectSources(projectID, sourceIDs); err != nil {
+		return nil, err
+	}
+	sources := make([][]string, len(sourceIDs))
+	for i, id := range sourceIDs {
+		sources[i] = []string{id}
+	}
+	return []interface{}{projectID, sources}, nil
+}
+
+// NotebookGuideMarkdown generates the notebook guide and renders it as a
+// Markdown document headed by the notebook title. There is no RPC for
+// reading back a previously generated guide, so the guide is always
+// generated. GenerateNotebookGuideResponse carries the guide as a single
+// text body whose paragraphs and bullets are kept in their original order.
+func (c *Client) NotebookGuideMarkdown(projectID string) (string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return "", err
+	}
+	project, err := c.GetProject(projectID)
+	if err != nil {
+		return "", fmt.Errorf("notebook guide markdown: %w", err)
+	}
+	guide, err := c.GenerateNotebookGuide(projectID)
+	if err != nil {
+		return "", err
+	}
+	return guideMarkdown(project, guide), nil
+}
+
+func guideMarkdown(project *Notebook, guide *pb.GenerateNotebookGuideResponse) string {
+	var b strings.Builder
+	title := strings.TrimSpace(project.GetEmoji() + " " + project.GetTitle())
+	if title == "" {
+		title = "Notebook Guide"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+
+	content := strings.ReplaceAll(guide.GetContent(), "\r\n", "\n")
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		line = strings.TrimRight(line, " \t")
+		// The guide uses "•" and "*" bullets; normalize them to Markdown
+		// list items while keeping their indentation.
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := line[:len(line)-len(trimmed)]
+		for _, bullet := range []string{"• ", "* "} {
+			if strings.HasPrefix(trimmed, bullet) {
+				line = indent + "- " + strings.TrimPrefix(trimmed, bullet)
+				break
+			}
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// SuggestedQuestions returns the questions NotebookLM suggests asking about
+// a notebook. They are delivered with the notebook guide, so this generates
+// the guide and reads the questions that follow its summary, falling back to
+// questions in the guide text itself. A notebook without enough sources for
+// suggestions yields an empty slice rather than an error.
+func (c *Client) SuggestedQuestions(projectID string) ([]string, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := c.rpc.Do(rpc.Call{
-		ID:         rpc.RPCGenerateOutline,
+		ID:         rpc.RPCGenerateNotebookGuide,
 		Args:       []interface{}{projectID},
 		NotebookID: projectID,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("generate outline: %w", err)
+		return nil, fmt.Errorf("suggested questions: %w", err)
+	}
+	return suggestedQuestions(resp), nil
+}
+
+// suggestedQuestions extracts questions from a raw GenerateNotebookGuide
+// payload: [content, ...suggestions]. Malformed or empty payloads yield an
+// empty slice.
+func suggestedQuestions(resp json.RawMessage) []string {
+	questions := []string{}
+	var data []interface{}
+	if err := json.Unmarshal(resp, &data); err != nil || len(data) == 0 {
+		return questions
+	}
+	seen := make(map[string]bool)
+	add := func(q string) {
+		q = strings.TrimSpace(q)
+		if strings.HasSuffix(q, "?") && !seen[q] {
+			seen[q] = true
+			questions = append(questions, q)
+		}
+	}
+	walkStrings(data[1:], add)
+	if len(questions) == 0 {
+		content, _ := data[0].(string)
+		for _, item := range parseFAQ(content) {
+			add(item.Question)
+		}
+	}
+	return questions
+}
+
+// FAQItem is one question and answer from a notebook's FAQ.
+type FAQItem struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// NotebookFAQ returns the question and answer pairs in the notebook guide.
+// No RPC is known to return an FAQ as structured data, so the guide is
+// generated, as NotebookGuideMarkdown does, and its text is split at each
+// line that ends in a question mark. Lines up to the next question form the
+// answer; a question listed without one, as with suggested questions, has
+// an empty Answer. A guide with no questions yields an empty slice.
+func (c *Client) NotebookFAQ(projectID string) ([]FAQItem, error) {
+	guide, err := c.GenerateNotebookGuide(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return parseFAQ(guide.GetContent()), nil
+}
+
+// faqMarkers are the list, emphasis and label prefixes stripped from FAQ
+// lines.
+var faqMarkers = []string{"• ", "* ", "- ", "**", "Q:", "A:", "Question:", "Answer:"}
+
+func parseFAQ(content string) []FAQItem {
+	clean := func(line string) string {
+		line = strings.TrimSpace(line)
+		for changed := true; changed; {
+			changed = false
+			for _, m := range faqMarkers {
+				if strings.HasPrefix(line, m) {
+					line = strings.TrimSpace(strings.TrimPrefix(line, m))
+					changed = true
+				}
+			}
+			// Numbered list items such as "3." or "3)".
+			if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" {
+				line = strings.TrimSpace(line[i+1:])
+				changed = true
+			}
+		}
+		return strings.TrimSpace(strings.TrimSuffix(line, "**"))
+	}
+
+	var items []FAQItem
+	var answer []string
+	flush := func() {
+		if len(items) > 0 {
+			items[len(items)-1].Answer = strings.Join(answer, "\n")
+		}
+		answer = nil
 	}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for _, line := range strings.Split(content, "\n") {
+		text := clean(line)
+		if text == "" {
+			continue
+		}
+		if strings.HasSuffix(text, "?") {
+			flush()
+			items = append(items, FAQItem{Question: text})
+			continue
+		}
+		if len(items) > 0 {
+			answer = append(answer, text)
+		}
+	}
+	flush()
+	if items == nil {
+		items = []FAQItem{}
+	}
+	return items
+}
 
+func (c *Client) GenerateOutline(projectID string) (*pb.GenerateOutlineResponse, error) {
+	return c.GenerateOutlineForSources(projectID, nil)
+}
+
+// GenerateOutlineForSources generates an outline from only the given
+// sources, which are checked against the project first. With no source IDs
+// the whole notebook is used, as in GenerateOutline. Like
+// GenerateNotebookGuideForSources it relies on an unverified payload slot.
+func (c *Client) GenerateOutlineForSources(projectID string, sourceIDs []string) (*pb.GenerateOutlineResponse, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	args, err := c.generationArgs(projectID, sourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("generate outline: %w", err)
+	}
 	var outline pb.GenerateOutlineResponse
-	if err := beprotojson.Unmarshal(resp, &outline); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := doProto(c, rpc.Call{
+		ID:         rpc.RPCGenerateOutline,
+		Args:       args,
+		NotebookID: projectID,
+	}, &outline); err != nil {
+		return nil, fmt.Errorf("generate outline: %w", err)
 	}
 	return &outline, nil
 }
 
+// OutlineNode is one entry of an outline, with its subsections.
+type OutlineNode struct {
+	Title    string        `json:"title"`
+	Children []OutlineNode `json:"children,omitempty"`
+}
+
+// outlineMarker matches list markers: bullets, decimal numbering such as
+// "2." or "2.1", roman numerals such as "IV." and letters such as "b)".
+var outlineMarker = regexp.MustCompile(`^([-*•+]|\d+(?:\.\d+)*[.)]?|[IVXLC]+\.|[A-Za-z][.)])\s+`)
+
+// OutlineTree converts a generated outline into a tree. The outline arrives
+// as text, so nesting is recovered from Markdown heading levels, list
+// indentation and dotted numbering such as "1.2". Entries keep the order in
+// which they appear.
+func OutlineTree(resp *pb.GenerateOutlineResponse) []OutlineNode {
+	type entry struct {
+		level int
+		node  *OutlineNode
+	}
+	root := &OutlineNode{}
+	stack := []entry{{level: -1, node: root}}
+	content := strings.ReplaceAll(resp.GetContent(), "\r\n", "\n")
+	for _, line := range strings.Split(content, "\n") {
+		title, level := outlineLine(line)
+		if title == "" {
+			continue
+		}
+		for len(stack) > 1 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+		parent.Children = append(parent.Children, OutlineNode{Title: title})
+		stack = append(stack, entry{level: level, node: &parent.Children[len(parent.Children)-1]})
+	}
+	return root.Children
+}
+
+// outlineLine returns the title of an outline line and a level that orders
+// its depth: headings by their number of "#", ranked above list items,
+// which nest by indentation and then by dotted numbering.
+func outlineLine(line string) (title string, level int) {
+	expanded := strings.ReplaceAll(line, "\t", "    ")
+	trimmed := strings.TrimLeft(expanded, " ")
+	indent := len(expanded) - len(trimmed)
+	trimmed = strings.TrimSpace(trimmed)
+	if trimmed == "" {
+		return "", 0
+	}
+	if strings.HasPrefix(trimmed, "#") {
+		hashes := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		return outlineTitle(trimmed[hashes:]), hashes
+	}
+	level = 10 + indent
+	if m := outlineMarker.FindStringSubmatch(trimmed); m != nil {
+		level += strings.Count(strings.TrimRight(m[1], ".)"), ".")
+		trimmed = trimmed[len(m[0]):]
+	}
+	return outlineTitle(trimmed), level
+}
+
+func outlineTitle(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "**") && strings.HasSuffix(s, "**") && len(s) > 4 {
+		s = s[2 : len(s)-2]
+	}
+	return strings.TrimSpace(s)
+}
+
 func (c *Client) GenerateSection(projectID string) (*pb.GenerateSectionResponse, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var section pb.GenerateSectionResponse
+	if err := doProto(c, rpc.Call{
 		ID:         rpc.RPCGenerateSection,
 		Args:       []interface{}{projectID},
 		NotebookID: projectID,
-	})
-	if err != nil {
+	}, &section); err != nil {
 		return nil, fmt.Errorf("generate section: %w", err)
 	}
-
-	var section pb.GenerateSectionResponse
-	if err := beprotojson.Unmarshal(resp, &section); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
-	}
 	return &section, nil
 }
 
 func (c *Client) StartDraft(projectID string) (*pb.StartDraftResponse, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var draft pb.StartDraftResponse
+	if err := doProto(c, rpc.Call{
 		ID:         rpc.RPCStartDraft,
 		Args:       []interface{}{projectID},
 		NotebookID: projectID,
-	})
-	if err != nil {
+	}, &draft); err != nil {
 		return nil, fmt.Errorf("start draft: %w", err)
 	}
-
-	var draft pb.StartDraftResponse
-	if err := beprotojson.Unmarshal(resp, &draft); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
-	}
 	return &draft, nil
 }
 
 func (c *Client) StartSection(projectID string) (*pb.StartSectionResponse, error) {
-	resp, err := c.rpc.Do(rpc.Call{
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var section pb.StartSectionResponse
+	if err := doProto(c, rpc.Call{
 		ID:         rpc.RPCStartSection,
 		Args:       []interface{}{projectID},
 		NotebookID: projectID,
+	}, &section); err != nil {
+		return nil, fmt.Errorf("start section: %w", err)
+	}
+	return &section, nil
+}
+
+// Account operations
+
+// UserInfo describes the Google account the client's cookies belong to.
+type UserInfo struct {
+	Email       string
+	DisplayName string
+}
+
+// GetUserInfo returns the account the client is authenticated as, using the
+// GetOrCreateAccount RPC the web UI issues on load.
+func (c *Client) GetUserInfo() (*UserInfo, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:   rpc.RPCGetOrCreateAccount,
+		Args: []interface{}{},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("start section: %w", err)
+		return nil, fmt.Errorf("get account: %w", err)
 	}
 
-	var section pb.StartSectionResponse
-	if err := beprotojson.Unmarshal(resp, &section); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	var data []interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, fmt.Errorf("parse response JSON: %w", err)
+	}
+
+	info := &UserInfo{}
+	findAccountStrings(data, info)
+	if info.Email == "" {
+		return nil, fmt.Errorf("account email not found in response")
+	}
+	return info, nil
+}
+
+// findAccountStrings walks the account response looking for the email
+// address. The display name, when present, is the string that follows the
+// email in the same array.
+func findAccountStrings(arr []interface{}, info *UserInfo) {
+	for i, v := range arr {
+		if info.Email != "" {
+			return
+		}
+		switch v := v.(type) {
+		case string:
+			if !strings.Contains(v, "@") || strings.ContainsAny(v, " /") {
+				continue
+			}
+			info.Email = v
+			if i+1 < len(arr) {
+				if name, ok := arr[i+1].(string); ok && !strings.Contains(name, "@") {
+					info.DisplayName = name
+				}
+			}
+		case []interface{}:
+			findAccountStrings(v, info)
+		}
 	}
-	return &section, nil
 }
 
 // Sharing operations
+//
+// TODO: collaborators are not supported yet. ShareProject only switches a
+// notebook between private and link-public, and no RPC for inviting an
+// account or listing the people a notebook is shared with has been captured.
+// Once it is, AddCollaborator(projectID, email, role) should take a Role of
+// viewer or editor, reject malformed emails before calling the server, and
+// map a sharing-restricted status (e.g. a Workspace policy forbidding
+// outside sharing) to a typed error; ListCollaborators(projectID) should
+// return each member's email and role.
 
 // ShareOption represents audio sharing visibility options
 type ShareOption int
@@ -1425,13 +4963,17 @@ const (
 
 // ShareAudioResult represents the response from sharing audio
 type ShareAudioResult struct {
-	ShareURL string
-	ShareID  string
-	IsPublic bool
+	ShareURL string `json:"share_url"`
+	ShareID  string `json:"share_id"`
+	IsPublic bool   `json:"is_public"`
 }
 
 // ShareAudio shares an audio overview with optional public access
 func (c *Client) ShareAudio(projectID string, shareOption ShareOption) (*ShareAudioResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := c.rpc.Do(rpc.Call{
 		ID: rpc.RPCShareAudio,
 		Args: []interface{}{
@@ -1444,30 +4986,105 @@ func (c *Client) ShareAudio(projectID string, shareOption ShareOption) (*ShareAu
 		return nil, fmt.Errorf("share audio: %w", err)
 	}
 
-	// Parse the raw response
+	result, err := parseShareAudioResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("share audio: %w", err)
+	}
+	result.IsPublic = shareOption == SharePublic
+	return result, nil
+}
+
+// parseShareAudioResponse reads a ShareAudio response of the form
+// [[shareURL, shareID, ...], ...]. A response without a share URL is an
+// error, so a change in its shape is not mistaken for an empty link.
+func parseShareAudioResponse(resp json.RawMessage) (*ShareAudioResult, error) {
 	var data []interface{}
 	if err := json.Unmarshal(resp, &data); err != nil {
 		return nil, fmt.Errorf("parse response: %w", err)
 	}
+	var share []interface{}
+	if len(data) > 0 {
+		share, _ = data[0].([]interface{})
+	}
+	result := &ShareAudioResult{}
+	if len(share) > 0 {
+		result.ShareURL, _ = share[0].(string)
+	}
+	if result.ShareURL == "" {
+		return nil, fmt.Errorf("no share URL in response structure %s", leadingShape(data))
+	}
+	if len(share) > 1 {
+		result.ShareID, _ = share[1].(string)
+	}
+	return result, nil
+}
+
+// ShareResult represents the sharing state of a notebook
+type ShareResult struct {
+	ShareURL string `json:"share_url"`
+	IsPublic bool   `json:"is_public"`
+}
+
+// ShareNotebook sets the visibility of an entire notebook and returns its
+// shareable link. SharePublic makes the notebook readable by anyone with the
+// link; SharePrivate restricts it to existing collaborators.
+func (c *Client) ShareNotebook(projectID string, shareOption ShareOption) (*ShareResult, error) {
+	projectID, err := normalizeProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.rpc.Do(rpc.Call{
+		ID: rpc.RPCShareProject,
+		Args: []interface{}{
+			projectID,
+			[]int{int(shareOption)},
+		},
+		NotebookID: projectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("share notebook: %w", err)
+	}
 
-	result := &ShareAudioResult{
-		IsPublic: shareOption == SharePublic,
+	result, err := parseShareProjectResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("share notebook: %w", err)
 	}
+	// The web UI copies the canonical notebook URL to the clipboard, so use
+	// it when the server does not return a link of its own.
+	if result.ShareURL == "" {
+		result.ShareURL = c.rpc.BaseURL() + "/notebook/" + projectID
+	}
+	return result, nil
+}
 
-	// Extract share URL and ID from response
+// parseShareProjectResponse reads a ShareProject response of the form
+// [[shareURL, ...], [access, ...]], where access is a ShareOption. The
+// visibility comes from the server rather than the request, so a setting
+// the server refused is not reported as applied; a response without it is
+// an error.
+func parseShareProjectResponse(resp json.RawMessage) (*ShareResult, error) {
+	var data []interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	result := &ShareResult{}
 	if len(data) > 0 {
-		if shareData, ok := data[0].([]interface{}); ok && len(shareData) > 0 {
-			if shareURL, ok := shareData[0].(string); ok {
-				result.ShareURL = shareURL
-			}
-			if len(shareData) > 1 {
-				if shareID, ok := shareData[1].(string); ok {
-					result.ShareID = shareID
-				}
-			}
+		if link, ok := data[0].([]interface{}); ok && len(link) > 0 {
+			result.ShareURL, _ = link[0].(string)
 		}
 	}
-
+	var access []interface{}
+	if len(data) > 1 {
+		access, _ = data[1].([]interface{})
+	}
+	if len(access) == 0 {
+		return nil, fmt.Errorf("no visibility in response structure %s", leadingShape(data))
+	}
+	v, ok := access[0].(float64)
+	if !ok {
+		return nil, fmt.Errorf("no visibility in response structure %s", leadingShape(data))
+	}
+	result.IsPublic = ShareOption(v) == SharePublic
 	return result, nil
 }
 
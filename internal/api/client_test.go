@@ -0,0 +1,2632 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/beprotojson"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+//go:embed testdata/*.txt
+var testdata embed.FS
+
+// fixtureTransport answers every request with the contents of a testdata file.
+type fixtureTransport string
+
+func (f fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := testdata.ReadFile("testdata/" + string(f))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+		Request:    req,
+	}, nil
+}
+
+func newFixtureClient(file string) *Client {
+	httpClient := &http.Client{Transport: fixtureTransport(file)}
+	return New("token", "cookies", WithRPCOptions(batchexecute.WithHTTPClient(httpClient)))
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// rpcFunc answers a call made through a fakeRPCFunc client. It is given the
+// RPC ID and the decoded call arguments and returns the data of the reply.
+// A statusReply error answers with that status instead; any other error is
+// returned by the transport, as a dropped connection would be.
+type rpcFunc func(id string, args []interface{}) (string, error)
+
+// statusReply is an rpcFunc error that answers the call with a frame
+// carrying no data and this status code.
+type statusReply int
+
+func (s statusReply) Error() string { return fmt.Sprintf("status %d", int(s)) }
+
+// fakeRPC returns a client whose calls are answered with the data given for
+// their RPC ID. A call to any other RPC fails the test.
+func fakeRPC(t *testing.T, data map[string]string, opts ...Option) *Client {
+	t.Helper()
+	return fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		d, ok := data[id]
+		if !ok {
+			t.Errorf("unexpected RPC %s", id)
+			return "", fmt.Errorf("unexpected RPC %s", id)
+		}
+		return d, nil
+	}, opts...)
+}
+
+// fakeRPCFunc returns a client whose calls are answered by fn.
+func fakeRPCFunc(t *testing.T, fn rpcFunc, opts ...Option) *Client {
+	t.Helper()
+	return fakeRPCTransport(t, fakeRPCRoundTrip(fn), opts...)
+}
+
+// fakeRPCTransport returns a client sending its requests to transport.
+func fakeRPCTransport(t *testing.T, transport http.RoundTripper, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport}))}, opts...)
+	return New("token", "cookies", opts...)
+}
+
+// fakeRPCRoundTrip answers batchexecute requests with fn.
+func fakeRPCRoundTrip(fn rpcFunc) roundTripFunc {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		id := req.URL.Query().Get("rpcids")
+		var freq [][][]interface{}
+		if err := json.Unmarshal([]byte(req.PostForm.Get("f.req")), &freq); err != nil || len(freq) == 0 || len(freq[0]) == 0 || len(freq[0][0]) < 2 {
+			return nil, fmt.Errorf("malformed f.req %q", req.PostForm.Get("f.req"))
+		}
+		argStr, _ := freq[0][0][1].(string)
+		var args []interface{}
+		if err := json.Unmarshal([]byte(argStr), &args); err != nil {
+			return nil, fmt.Errorf("malformed arguments %q: %w", argStr, err)
+		}
+		data, err := fn(id, args)
+		var status statusReply
+		if errors.As(err, &status) {
+			return frameResponse(req, []interface{}{"wrb.fr", id, nil, nil, nil, []int{int(status)}, "generic"}), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return rpcResponse(req, id, data), nil
+	})
+}
+
+// rpcResponse answers req with a single wrb.fr frame carrying data.
+func rpcResponse(req *http.Request, id, data string) *http.Response {
+	return frameResponse(req, []interface{}{"wrb.fr", id, data, nil, nil, nil, "generic"})
+}
+
+// frameResponse answers req with a batchexecute response holding frame.
+func frameResponse(req *http.Request, frame []interface{}) *http.Response {
+	body, _ := json.Marshal([]interface{}{frame})
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(")]}'\n\n" + string(body))),
+		Request:    req,
+	}
+}
+
+func TestWithAcceptLanguage(t *testing.T) {
+	var got *http.Request
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return fixtureTransport("guide_fr.txt").RoundTrip(req)
+	})
+	c := New("token", "cookies",
+		WithAcceptLanguage("fr-FR"),
+		WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport})),
+	)
+
+	guide, err := c.GenerateNotebookGuide("proj-1")
+	if err != nil {
+		t.Fatalf("GenerateNotebookGuide() error = %v", err)
+	}
+	if lang := got.Header.Get("Accept-Language"); lang != "fr-FR" {
+		t.Errorf("Accept-Language = %q, want fr-FR", lang)
+	}
+	if hl := got.URL.Query().Get("hl"); hl != "fr-FR" {
+		t.Errorf("hl = %q, want fr-FR", hl)
+	}
+	if !strings.HasPrefix(guide.GetContent(), "Ces sources") {
+		t.Errorf("guide content = %q, want the French fixture", guide.GetContent())
+	}
+}
+
+func TestGetProjectNotFound(t *testing.T) {
+	c := newFixtureClient("project_not_found.txt")
+	_, err := c.GetProject("deleted-project-id")
+	if !errors.Is(err, ErrProjectNotFound) {
+		t.Fatalf("GetProject() error = %v, want ErrProjectNotFound", err)
+	}
+	if !strings.Contains(err.Error(), "deleted-project-id") {
+		t.Errorf("error %q does not mention the project ID", err)
+	}
+}
+
+func TestGetAudioOverviewAbsent(t *testing.T) {
+	c := newFixtureClient("audio_none.txt")
+	got, err := c.GetAudioOverview("proj-1")
+	if err != nil {
+		t.Fatalf("GetAudioOverview() error = %v", err)
+	}
+	if got != nil {
+		t.Errorf("GetAudioOverview() = %+v, want nil", got)
+	}
+}
+
+func TestReorderProjectSources(t *testing.T) {
+	project := &pb.Project{}
+	err := beprotojson.Unmarshal([]byte(`["Reading list", [
+		[["src-a"], "Alpha"],
+		[["src-b"], "Beta"],
+		[["src-c"], "Gamma"]
+	], "proj-1", "📚"]`), project)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		sourceIDs []string
+		wantOrder []string
+		wantErr   error
+	}{
+		{
+			name:      "full order",
+			sourceIDs: []string{"src-c", "src-a", "src-b"},
+			wantOrder: []string{"src-c", "src-a", "src-b"},
+		},
+		{
+			name:      "pin single source",
+			sourceIDs: []string{"src-b"},
+			wantOrder: []string{"src-b", "src-a", "src-c"},
+		},
+		{
+			name:      "unknown source",
+			sourceIDs: []string{"src-z"},
+			wantErr:   ErrSourceNotInProject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reorderProjectSources(project, tt.sourceIDs)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("reorderProjectSources() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reorderProjectSources() error = %v", err)
+			}
+
+			var order []string
+			for _, src := range got.Sources {
+				order = append(order, src.GetSourceId().GetSourceId())
+			}
+			if diff := cmp.Diff(tt.wantOrder, order); diff != "" {
+				t.Errorf("source order diff (-want +got):\n%s", diff)
+			}
+
+			// Everything other than the order must survive the round trip.
+			got.Sources = nil
+			want := &pb.Project{Title: "Reading list", ProjectId: "proj-1", Emoji: "📚"}
+			if !proto.Equal(want, got) {
+				t.Errorf("project fields = %v, want %v", got, want)
+			}
+			if len(project.Sources) != 3 || project.Sources[0].GetSourceId().GetSourceId() != "src-a" {
+				t.Error("original project was modified")
+			}
+		})
+	}
+}
+
+func TestLocalSourceNeedsUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("# v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile() error = %v", err)
+	}
+
+	store := SourceHashes{"src-1": hash}
+	c := New("token", "cookies", WithSourceHashStore(store))
+
+	if stale, err := c.LocalSourceNeedsUpdate("src-1", path); err != nil || stale {
+		t.Errorf("unchanged file: LocalSourceNeedsUpdate() = %v, %v; want false, nil", stale, err)
+	}
+	if stale, err := c.LocalSourceNeedsUpdate("src-2", path); err != nil || !stale {
+		t.Errorf("unknown source: LocalSourceNeedsUpdate() = %v, %v; want true, nil", stale, err)
+	}
+
+	if err := os.WriteFile(path, []byte("# v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err := c.LocalSourceNeedsUpdate("src-1", path); err != nil || !stale {
+		t.Errorf("changed file: LocalSourceNeedsUpdate() = %v, %v; want true, nil", stale, err)
+	}
+}
+
+func TestAddSourceFromFileHash(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("# v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile() error = %v", err)
+	}
+
+	store := SourceHashes{}
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		// The file changes while the upload is in flight.
+		if err := os.WriteFile(path, []byte("# v2\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return `[[["src-1"]]]`, nil
+	}, WithSourceHashStore(store))
+
+	if _, err := c.AddSourceFromFile(projectID, path); err != nil {
+		t.Fatalf("AddSourceFromFile() error = %v", err)
+	}
+	if store["src-1"] != uploaded {
+		t.Errorf("stored hash = %q, want %q, the hash of the uploaded content", store["src-1"], uploaded)
+	}
+	if stale, err := c.LocalSourceNeedsUpdate("src-1", path); err != nil || !stale {
+		t.Errorf("file changed during upload: LocalSourceNeedsUpdate() = %v, %v; want true, nil", stale, err)
+	}
+}
+
+// zeros is an endless reader of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestAddSourceTooLarge(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		t.Errorf("unexpected %s call for an oversized upload", id)
+		return `[[["src-1"]]]`, nil
+	})
+
+	r := io.LimitReader(zeros{}, MaxUploadSize+1)
+	if _, err := c.AddSourceFromReader(projectID, r, "big.bin"); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("AddSourceFromReader() error = %v, want %v", err, ErrFileTooLarge)
+	}
+
+	// A sparse file has the size without the disk usage.
+	path := filepath.Join(t.TempDir(), "big.pdf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(MaxUploadSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := c.AddSourceFromFile(projectID, path); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("AddSourceFromFile() error = %v, want %v", err, ErrFileTooLarge)
+	}
+}
+
+func TestAudioOverviewArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts AudioOverviewOptions
+		want string
+	}{
+		{
+			name: "defaults",
+			opts: AudioOverviewOptions{Instructions: "be brief"},
+			want: `["proj-1",0,["be brief"]]`,
+		},
+		{
+			name: "language only",
+			opts: AudioOverviewOptions{Instructions: "be brief", Language: "ja"},
+			want: `["proj-1",0,["be brief"],null,"ja"]`,
+		},
+		{
+			name: "all options",
+			opts: AudioOverviewOptions{
+				Instructions: "be brief",
+				FocusSources: []string{"src-a", "src-b"},
+				Language:     "ja",
+				Format:       AudioFormatSingleHost,
+				Interactive:  true,
+			},
+			want: `["proj-1",1,["be brief"],[["src-a"],["src-b"]],"ja",[true]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(audioOverviewArgs("proj-1", tt.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("audioOverviewArgs() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultSourceTitle(t *testing.T) {
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "first line", content: "Meeting notes\nsecond line", want: "Meeting notes"},
+		{name: "leading blank lines", content: "\n  \n  東京の議事録  \nbody", want: "東京の議事録"},
+		{name: "long line", content: strings.Repeat("あ", 100), want: strings.Repeat("あ", 80) + "…"},
+		{name: "empty", content: "", want: "Pasted text 2024-11-20 12:00:00"},
+		{name: "whitespace only", content: " \n\t\n", want: "Pasted text 2024-11-20 12:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaultSourceTitle(tt.content, now)
+			if got == "" {
+				t.Fatal("defaultSourceTitle() returned an empty title")
+			}
+			if got != tt.want {
+				t.Errorf("defaultSourceTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSourceFromTextDefaultTitle(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	var sentTitle interface{}
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id != rpc.RPCAddSources {
+			t.Errorf("unexpected RPC %s", id)
+		}
+		entry := args[0].([]interface{})[0].([]interface{})
+		sentTitle = entry[1].([]interface{})[0]
+		return `[[["src-1"]]]`, nil
+	})
+
+	tests := []struct {
+		content, title string
+		want           string
+	}{
+		{content: "Meeting notes\nbody", title: "", want: "Meeting notes"},
+		{content: "Meeting notes\nbody", title: "  ", want: "Meeting notes"},
+		{content: "Meeting notes\nbody", title: "Minutes", want: "Minutes"},
+		{content: " \n\t", title: ""},
+	}
+	for _, tt := range tests {
+		sentTitle = nil
+		if _, err := c.AddSourceFromText(projectID, tt.content, tt.title); err != nil {
+			t.Fatalf("AddSourceFromText(%q, %q) error = %v", tt.content, tt.title, err)
+		}
+		got, _ := sentTitle.(string)
+		if strings.TrimSpace(got) == "" {
+			t.Errorf("AddSourceFromText(%q, %q) sent title %v, want a non-empty title", tt.content, tt.title, sentTitle)
+		}
+		if tt.want != "" && got != tt.want {
+			t.Errorf("AddSourceFromText(%q, %q) sent title %q, want %q", tt.content, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestGuideMarkdown(t *testing.T) {
+	project := &pb.Project{Title: "Reading list", Emoji: "📚"}
+	guide := &pb.GenerateNotebookGuideResponse{
+		Content: "These sources cover Go tooling.\r\n\r\nKey topics:\r\n• Modules\r\n  * Workspaces\r\n",
+	}
+	want := "# 📚 Reading list\n\n" +
+		"These sources cover Go tooling.\n" +
+		"\n" +
+		"Key topics:\n" +
+		"- Modules\n" +
+		"  - Workspaces\n"
+	if got := guideMarkdown(project, guide); got != want {
+		t.Errorf("guideMarkdown() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSourceErrorReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		source *pb.Source
+		want   string
+	}{
+		{
+			name:   "reason in settings",
+			raw:    `[[["src-1"]], "scan.pdf", [null, 0], [null, 3, "Unsupported file format"]]`,
+			source: &pb.Source{},
+			want:   "Unsupported file format",
+		},
+		{
+			name: "warning codes only",
+			raw:  `[[["src-1"]], "scan.pdf", [null, 0], [null, 3], [7]]`,
+			source: &pb.Source{
+				Warnings: []*wrapperspb.Int32Value{{Value: 7}},
+			},
+			want: "processing failed (codes 7)",
+		},
+		{
+			name:   "nothing known",
+			raw:    `[[["src-1"]], "scan.pdf", [null, 0], [null, 3]]`,
+			source: &pb.Source{},
+			want:   "processing failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw []interface{}
+			if err := json.Unmarshal([]byte(tt.raw), &raw); err != nil {
+				t.Fatal(err)
+			}
+			if got := sourceErrorReason(raw, tt.source); got != tt.want {
+				t.Errorf("sourceErrorReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmoji(t *testing.T) {
+	tests := []struct {
+		name    string
+		emoji   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", emoji: "", want: ""},
+		{name: "single", emoji: "📚", want: "📚"},
+		{name: "surrounding space", emoji: " 📙 ", want: "📙"},
+		{name: "text presentation", emoji: "❤", want: "❤️"},
+		{name: "variation selector", emoji: "🕵️", want: "🕵️"},
+		{name: "skin tone", emoji: "👍🏽", want: "👍🏽"},
+		{name: "zwj sequence", emoji: "👩‍💻", want: "👩‍💻"},
+		{name: "flag", emoji: "🇯🇵", want: "🇯🇵"},
+		{name: "keycap", emoji: "1️⃣", want: "1️⃣"},
+		{name: "tag sequence", emoji: "🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", want: "🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"},
+		{name: "two emoji", emoji: "📚📚", wantErr: true},
+		{name: "plain text", emoji: "book", wantErr: true},
+		{name: "emoji and text", emoji: "📚 notes", wantErr: true},
+		{name: "dangling zwj", emoji: "👩‍", wantErr: true},
+		{name: "lone regional indicator", emoji: "🇯", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeEmoji(tt.emoji)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEmoji) {
+					t.Errorf("NormalizeEmoji(%q) error = %v, want ErrInvalidEmoji", tt.emoji, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeEmoji(%q) error = %v", tt.emoji, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeEmoji(%q) = %q, want %q", tt.emoji, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActOnSourcesUnknownAction(t *testing.T) {
+	c := fakeRPC(t, nil)
+
+	err := c.ActOnSources("proj-1", SourceAction("enabel"), []string{"src-a"})
+	if !errors.Is(err, ErrUnknownSourceAction) {
+		t.Errorf("ActOnSources() error = %v, want ErrUnknownSourceAction", err)
+	}
+}
+
+func TestAudioFormat(t *testing.T) {
+	encode := func(b string) string { return base64.StdEncoding.EncodeToString([]byte(b)) }
+	tests := []struct {
+		name     string
+		audioArr []interface{}
+		data     string
+		want     string
+		wantExt  string
+	}{
+		{
+			name:     "reported in response",
+			audioArr: []interface{}{3.0, encode("RIFF\x00\x00\x00\x00WAVEfmt "), "id", "title", "audio/mpeg"},
+			data:     encode("RIFF\x00\x00\x00\x00WAVEfmt "),
+			want:     "audio/mpeg",
+			wantExt:  ".mp3",
+		},
+		{name: "sniff wav", data: encode("RIFF\x24\x00\x00\x00WAVEfmt "), want: "audio/wav", wantExt: ".wav"},
+		{name: "sniff mp3 id3", data: encode("ID3\x04\x00\x00\x00\x00\x00\x00\x00\x00"), want: "audio/mpeg", wantExt: ".mp3"},
+		{name: "sniff m4a", data: encode("\x00\x00\x00\x20ftypM4A \x00\x00"), want: "audio/mp4", wantExt: ".m4a"},
+		{name: "unknown", data: encode("not audio at all"), want: "", wantExt: ".wav"},
+		{name: "empty", data: "", want: "", wantExt: ".wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := audioFormat(tt.audioArr, tt.data)
+			if got != tt.want {
+				t.Errorf("audioFormat() = %q, want %q", got, tt.want)
+			}
+			r := &AudioOverviewResult{Format: got}
+			if ext := r.FileExtension(); ext != tt.wantExt {
+				t.Errorf("FileExtension() = %q, want %q", ext, tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestProjectStats(t *testing.T) {
+	project := &pb.Project{
+		ProjectId: "proj-1",
+		Title:     "Reading list",
+		Sources: []*pb.Source{
+			{Metadata: &pb.SourceMetadata{SourceType: pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS}},
+			{Metadata: &pb.SourceMetadata{SourceType: pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS}},
+			{
+				Metadata: &pb.SourceMetadata{SourceType: pb.SourceType_SOURCE_TYPE_YOUTUBE_VIDEO},
+				Settings: &pb.SourceSettings{Status: pb.SourceSettings_SOURCE_STATUS_DISABLED},
+			},
+			{Settings: &pb.SourceSettings{Status: pb.SourceSettings_SOURCE_STATUS_ERROR}},
+		},
+	}
+
+	got := projectStats(project)
+	if got.SourceCount != 4 || got.DisabledSources != 1 || got.FailedSources != 1 {
+		t.Errorf("projectStats() counts = %d/%d/%d, want 4/1/1", got.SourceCount, got.DisabledSources, got.FailedSources)
+	}
+	want := map[string]int{
+		"SOURCE_TYPE_GOOGLE_DOCS":   2,
+		"SOURCE_TYPE_YOUTUBE_VIDEO": 1,
+		"SOURCE_TYPE_UNSPECIFIED":   1,
+	}
+	if diff := cmp.Diff(want, got.SourcesByType); diff != "" {
+		t.Errorf("SourcesByType diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseNotebookURL(t *testing.T) {
+	const id = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: id, want: id},
+		{input: "  " + id + "\n", want: id},
+		{input: "https://notebooklm.google.com/notebook/" + id, want: id},
+		{input: "https://notebooklm.google.com/notebook/" + id + "?authuser=1#sources", want: id},
+		{input: "https://notebooklm.google.com/u/1/notebook/" + id + "/", want: id},
+		{input: "http://127.0.0.1:8080/notebook/" + id, want: id},
+		{input: "", wantErr: true},
+		{input: "https://notebooklm.google.com/", wantErr: true},
+		{input: "notebooklm.google.com/notebook/" + id, want: id},
+		{input: "ftp://notebooklm.google.com/notebook/" + id, wantErr: true},
+		{input: "my notebook", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseNotebookURL(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidNotebookID) {
+					t.Errorf("ParseNotebookURL(%q) error = %v, want ErrInvalidNotebookID", tt.input, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseNotebookURL(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindYouTubeSource(t *testing.T) {
+	youtube := func(id, videoID string, modified int64) *pb.Source {
+		return &pb.Source{
+			SourceId: &pb.SourceId{SourceId: id},
+			Metadata: &pb.SourceMetadata{
+				LastModifiedTime: timestamppb.New(time.Unix(modified, 0)),
+				MetadataType: &pb.SourceMetadata_Youtube{
+					Youtube: &pb.YoutubeSourceMetadata{VideoId: videoID},
+				},
+			},
+		}
+	}
+	project := &pb.Project{Sources: []*pb.Source{
+		{SourceId: &pb.SourceId{SourceId: "doc"}},
+		youtube("old", "hkhDdcM5V94", 1728034802),
+		youtube("new", "hkhDdcM5V94", 1731910459),
+		youtube("other", "dQw4w9WgXcQ", 1731910460),
+	}}
+
+	if got := findYouTubeSource(project, "hkhDdcM5V94", nil); got != "new" {
+		t.Errorf("findYouTubeSource() = %q, want %q", got, "new")
+	}
+	if got := findYouTubeSource(project, "missing", nil); got != "" {
+		t.Errorf("findYouTubeSource() = %q, want empty", got)
+	}
+}
+
+func TestDiffSources(t *testing.T) {
+	project := &pb.Project{Sources: []*pb.Source{
+		{SourceId: &pb.SourceId{SourceId: "s1"}, Title: "notes.md"},
+		{SourceId: &pb.SourceId{SourceId: "s2"}, Title: "old.pdf"},
+		{
+			SourceId: &pb.SourceId{SourceId: "s3"},
+			Title:    "Prompt Workshop",
+			Metadata: &pb.SourceMetadata{MetadataType: &pb.SourceMetadata_Youtube{
+				Youtube: &pb.YoutubeSourceMetadata{YoutubeUrl: "https://www.youtube.com/watch?v=hkhDdcM5V94"},
+			}},
+		},
+		{SourceId: &pb.SourceId{SourceId: "s4"}, Title: "The Go Blog"},
+	}}
+	urls := map[string]string{"s4": "https://go.dev/blog"}
+	desired := []string{"notes.md", "new.txt", "https://www.youtube.com/watch?v=hkhDdcM5V94", "https://go.dev/blog"}
+
+	toAdd, toRemove := diffSources(project, urls, desired)
+	if diff := cmp.Diff([]string{"new.txt"}, toAdd); diff != "" {
+		t.Errorf("toAdd diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"s2"}, toRemove); diff != "" {
+		t.Errorf("toRemove diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffSourcesWebURL(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	project := `[["Reading",[` +
+		`[["web-1"],"The Go Blog",[null,1200,[1700000000,0],null,7,null,null,["https://go.dev/blog"]],[null,1]],` +
+		`[["yt-1"],"Prompt Workshop",[null,900,[1700000000,0],null,9,["https://www.youtube.com/watch?v=hkhDdcM5V94","hkhDdcM5V94"]],[null,1]],` +
+		`[["txt-1"],"notes.md",[null,40,[1700000000,0]],[null,1]]` +
+		`],"` + projectID + `","📚"]]`
+	var reads int
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id != rpc.RPCGetProject {
+			t.Errorf("unexpected RPC %s", id)
+		}
+		reads++
+		return project, nil
+	})
+
+	toAdd, toRemove, err := c.DiffSources(projectID, []string{"https://go.dev/blog", "notes.md"})
+	if err != nil {
+		t.Fatalf("DiffSources() error = %v", err)
+	}
+	if reads != 1 {
+		t.Errorf("GetProject sent %d times, want 1 for both titles and URLs", reads)
+	}
+	if len(toAdd) != 0 {
+		t.Errorf("toAdd = %q, want the web page matched by its URL", toAdd)
+	}
+	if diff := cmp.Diff([]string{"yt-1"}, toRemove); diff != "" {
+		t.Errorf("toRemove diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsDriveSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *pb.Source
+		want   bool
+	}{
+		{"nil source", nil, false},
+		{"no metadata", &pb.Source{Title: "notes.txt"}, false},
+		{"google docs type", &pb.Source{Metadata: &pb.SourceMetadata{SourceType: pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS}}, true},
+		{"google sheets type", &pb.Source{Metadata: &pb.SourceMetadata{SourceType: pb.SourceType_SOURCE_TYPE_GOOGLE_SHEETS}}, true},
+		{"google docs oneof", &pb.Source{Metadata: &pb.SourceMetadata{
+			MetadataType: &pb.SourceMetadata_GoogleDocs{GoogleDocs: &pb.GoogleDocsSourceMetadata{DocumentId: "doc-1"}},
+		}}, true},
+		{"youtube", &pb.Source{Metadata: &pb.SourceMetadata{SourceType: pb.SourceType_SOURCE_TYPE_YOUTUBE_VIDEO}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDriveSource(tt.source); got != tt.want {
+				t.Errorf("IsDriveSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimalSourceNoPanic(t *testing.T) {
+	minimal := &pb.Source{}
+	project := &Notebook{ProjectId: "proj-1", Sources: []*pb.Source{minimal, nil}}
+
+	if IsDriveSource(minimal) {
+		t.Error("IsDriveSource(minimal) = true, want false")
+	}
+	if got := sourceStatus(minimal); got != SourceStatusUnknown {
+		t.Errorf("sourceStatus(minimal) = %v, want SourceStatusUnknown", got)
+	}
+	if got := sourceErrorReason(nil, nil); got != "processing failed" {
+		t.Errorf("sourceErrorReason(nil, nil) = %q", got)
+	}
+	if got := projectStats(project).SourceCount; got != 2 {
+		t.Errorf("SourceCount = %d, want 2", got)
+	}
+	findYouTubeSource(project, "hkhDdcM5V94", nil)
+
+	c := New("token", "cookies")
+	for _, raw := range [][]interface{}{
+		{nil, "Title only"},
+		{nil, nil, []interface{}{[]interface{}{"drive"}}},
+		{nil, nil, []interface{}{[]interface{}{"drive"}, nil, nil, nil, 1.0}},
+	} {
+		if _, err := c.analyzeRawSourceStructure(raw, &SourceFreshnessResult{}); err != nil {
+			t.Errorf("analyzeRawSourceStructure(%v) error = %v", raw, err)
+		}
+	}
+	if _, err := c.analyzeLength5Metadata([]interface{}{nil}, &SourceFreshnessResult{}); err != nil {
+		t.Errorf("analyzeLength5Metadata() error = %v", err)
+	}
+}
+
+func TestAddSourcesFromURLs(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		freq := req.PostForm.Get("f.req")
+		switch {
+		case strings.Contains(freq, "broken.example"):
+			body := ")]}'\n\n[[\"er\",null,null,null,null,[3]]]"
+			return &http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request",
+				Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+		case strings.Contains(freq, "dQw4w9WgXcQ"):
+			return rpcResponse(req, rpc.RPCAddSources, `[[["src-yt"]]]`), nil
+		default:
+			return rpcResponse(req, rpc.RPCAddSources, `[[["src-web"]]]`), nil
+		}
+	})
+	c := New("token", "cookies", WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport})))
+
+	urls := []string{
+		"https://example.com/article",
+		"https://broken.example/page",
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+	}
+	refs, err := c.AddSourcesFromURLs("proj-1", urls)
+	if err != nil {
+		t.Fatalf("AddSourcesFromURLs() error = %v", err)
+	}
+	if len(refs) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(refs), len(urls))
+	}
+	for i, ref := range refs {
+		if ref.URL != urls[i] {
+			t.Errorf("refs[%d].URL = %q, want %q", i, ref.URL, urls[i])
+		}
+	}
+	if refs[0].Err != nil || refs[0].SourceID != "src-web" {
+		t.Errorf("refs[0] = %+v, want src-web", refs[0])
+	}
+	if refs[1].Err == nil {
+		t.Errorf("refs[1] = %+v, want error", refs[1])
+	}
+	if refs[2].Err != nil || refs[2].SourceID != "src-yt" {
+		t.Errorf("refs[2] = %+v, want src-yt", refs[2])
+	}
+}
+
+func TestSourceProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *pb.Source
+		want   float64
+	}{
+		{"no settings", &pb.Source{}, 0},
+		{"processing", &pb.Source{Settings: &pb.SourceSettings{}}, 0.5},
+		{"enabled", &pb.Source{Settings: &pb.SourceSettings{Status: pb.SourceSettings_SOURCE_STATUS_ENABLED}}, 1},
+		{"disabled", &pb.Source{Settings: &pb.SourceSettings{Status: pb.SourceSettings_SOURCE_STATUS_DISABLED}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourceProgress(tt.source); got != tt.want {
+				t.Errorf("sourceProgress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceStats(t *testing.T) {
+	c := fakeRPC(t, map[string]string{
+		rpc.RPCLoadSource: `[[["src-1"]],"Talk",[null,15108,[1728034802,578385000],null,9],[null,2]]`,
+	})
+	stats, err := c.SourceStats("src-1")
+	if err != nil {
+		t.Fatalf("SourceStats() error = %v", err)
+	}
+	if stats.WordCount != 15108 {
+		t.Errorf("WordCount = %d, want 15108", stats.WordCount)
+	}
+
+	got, err := json.Marshal(sourceStats([]interface{}{[]interface{}{"src-1"}, "Doc"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "{}" {
+		t.Errorf("stats without metadata marshal to %s, want {}", got)
+	}
+}
+
+func TestProjectTimestamps(t *testing.T) {
+	var project pb.Project
+	raw := `["Notes",[],"proj-1","📚",null,[1,true,null,null,null,[1728034802,0],null,null,[1700000000,0]]]`
+	if err := beprotojson.Unmarshal([]byte(raw), &project); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	created, modified := projectTimestamps(&project)
+	if want := time.Unix(1700000000, 0); !created.Equal(want) {
+		t.Errorf("created = %v, want %v", created, want)
+	}
+	if want := time.Unix(1728034802, 0); !modified.Equal(want) {
+		t.Errorf("modified = %v, want %v", modified, want)
+	}
+
+	created, modified = projectTimestamps(&pb.Project{})
+	if !created.IsZero() || !modified.IsZero() {
+		t.Errorf("timestamps without metadata = %v, %v, want zero times", created, modified)
+	}
+}
+
+func TestWithDecoder(t *testing.T) {
+	// A response shape the default decoder does not understand: the project
+	// wrapped in an extra array.
+	payload := `[["Reading list",[],"proj-1","📚"]]`
+	unwrap := func(data []byte, m proto.Message) error {
+		var outer []json.RawMessage
+		if err := json.Unmarshal(data, &outer); err != nil || len(outer) == 0 {
+			return fmt.Errorf("unexpected shape: %s", data)
+		}
+		return beprotojson.Unmarshal(outer[0], m)
+	}
+	c := fakeRPC(t, map[string]string{rpc.RPCGetProject: payload}, WithDecoder(&pb.Project{}, unwrap))
+
+	project, err := c.GetProject("proj-1")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if project.GetTitle() != "Reading list" || project.GetProjectId() != "proj-1" {
+		t.Errorf("GetProject() = %v", project)
+	}
+}
+
+func TestSourceEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry []interface{}
+		want  string
+	}{
+		{"text", TextSourceEntry("Notes", "hello"), `[null,["Notes","hello"],null,2]`},
+		{"url", URLSourceEntry("https://example.com"), `[null,null,["https://example.com"]]`},
+		{"youtube", YouTubeSourceEntry("hkhDdcM5V94"), `[null,null,"hkhDdcM5V94",null,9]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("entry = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFAQ(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []FAQItem
+	}{
+		{
+			name:    "no questions",
+			content: "These sources cover Go tooling.",
+			want:    []FAQItem{},
+		},
+		{
+			name: "labelled pairs",
+			content: "Frequently Asked Questions\r\n\r\n" +
+				"1. **What is a module?**\r\n" +
+				"A module is a collection of packages.\r\n" +
+				"It is versioned as a unit.\r\n\r\n" +
+				"Q: How do workspaces help?\r\n" +
+				"A: They let you edit several modules at once.\r\n",
+			want: []FAQItem{
+				{Question: "What is a module?", Answer: "A module is a collection of packages.\nIt is versioned as a unit."},
+				{Question: "How do workspaces help?", Answer: "They let you edit several modules at once."},
+			},
+		},
+		{
+			name: "suggested questions",
+			content: "Summary of the notebook.\n\n" +
+				"• Why use table-driven tests?\n" +
+				"• When should I vendor dependencies?\n",
+			want: []FAQItem{
+				{Question: "Why use table-driven tests?"},
+				{Question: "When should I vendor dependencies?"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, parseFAQ(tt.content)); diff != "" {
+				t.Errorf("parseFAQ() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSourceStatusList(t *testing.T) {
+	project := &Notebook{Sources: []*pb.Source{
+		{SourceId: &pb.SourceId{SourceId: "src-a"}, Settings: &pb.SourceSettings{Status: pb.SourceSettings_SOURCE_STATUS_ENABLED}},
+		{SourceId: &pb.SourceId{SourceId: "src-b"}, Settings: &pb.SourceSettings{Status: pb.SourceSettings_SOURCE_STATUS_ERROR}},
+		{SourceId: &pb.SourceId{SourceId: "src-c"}},
+		{SourceId: &pb.SourceId{SourceId: "src-d"}, Settings: &pb.SourceSettings{}},
+	}}
+
+	got := sourceStatusList(project, []string{"src-c", "missing", "src-d", "src-a"})
+	want := []SourceStatusEntry{
+		{SourceID: "src-c", Status: SourceStatusUnknown},
+		{SourceID: "missing", Status: SourceStatusUnknown},
+		{SourceID: "src-d", Status: pb.SourceSettings_SOURCE_STATUS_UNSPECIFIED},
+		{SourceID: "src-a", Status: pb.SourceSettings_SOURCE_STATUS_ENABLED},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sourceStatusList() mismatch (-want +got):\n%s", diff)
+	}
+
+	got = sourceStatusList(project, nil)
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, e.SourceID)
+	}
+	if diff := cmp.Diff([]string{"src-a", "src-b", "src-c", "src-d"}, ids); diff != "" {
+		t.Errorf("sourceStatusList(nil) order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSourceStatuses(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	c := fakeRPC(t, map[string]string{
+		rpc.RPCGetProject: `["Sweep",[` +
+			`[["src-on"],"On",null,[null,1]],` +
+			`[["src-bare"],"Bare"]` +
+			`],"` + projectID + `","📚"]`,
+	})
+
+	got, err := c.SourceStatuses(projectID)
+	if err != nil {
+		t.Fatalf("SourceStatuses() error = %v", err)
+	}
+	want := map[string]pb.SourceSettings_SourceStatus{
+		"src-on":   pb.SourceSettings_SOURCE_STATUS_ENABLED,
+		"src-bare": SourceStatusUnknown,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SourceStatuses() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExtForAudioMIME(t *testing.T) {
+	tests := map[string]string{
+		"audio/wav":                ".wav",
+		"audio/x-wav":              ".wav",
+		"audio/mpeg":               ".mp3",
+		"Audio/MPEG":               ".mp3",
+		"audio/ogg; codecs=opus":   ".ogg",
+		"audio/mp4":                ".m4a",
+		"":                         ".wav",
+		"application/octet-stream": ".wav",
+	}
+	for mimeType, want := range tests {
+		if got := extForAudioMIME(mimeType); got != want {
+			t.Errorf("extForAudioMIME(%q) = %q, want %q", mimeType, got, want)
+		}
+	}
+}
+
+func TestAudioSaveToFile(t *testing.T) {
+	dir := t.TempDir()
+	result := &AudioOverviewResult{
+		AudioData: base64.StdEncoding.EncodeToString([]byte("ID3 audio")),
+		Format:    "audio/mpeg",
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(dir, "episode"), filepath.Join(dir, "episode.mp3")},
+		{filepath.Join(dir, "episode.wav"), filepath.Join(dir, "episode.wav")},
+	}
+	for _, tt := range tests {
+		got, err := result.SaveToFile(tt.path)
+		if err != nil {
+			t.Fatalf("SaveToFile(%q) error = %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("SaveToFile(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if data, err := os.ReadFile(got); err != nil || string(data) != "ID3 audio" {
+			t.Errorf("ReadFile(%q) = %q, %v", got, data, err)
+		}
+	}
+}
+
+func TestCreateProjectWithSources(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch {
+		case id == rpc.RPCCreateProject:
+			return `["Scaffold",[],"` + projectID + `","📁"]`, nil
+		case strings.Contains(fmt.Sprint(args), "bad.example"):
+			return "", nil
+		default:
+			return `[[["src-1"]]]`, nil
+		}
+	})
+
+	project, refs, err := c.CreateProjectWithSources("Scaffold", "📁", []SourceInput{
+		{URL: "https://example.com/a"},
+		{Text: "Meeting notes", Title: "Notes"},
+		{URL: "https://bad.example/b"},
+		{},
+	})
+	if project.GetProjectId() != projectID {
+		t.Fatalf("project = %v, want ID %s", project, projectID)
+	}
+	if len(refs) != 4 {
+		t.Fatalf("got %d refs, want 4", len(refs))
+	}
+	if refs[0].Err != nil || refs[1].Err != nil || refs[0].SourceID != "src-1" {
+		t.Errorf("refs[0], refs[1] = %+v, %+v, want success", refs[0], refs[1])
+	}
+	if refs[2].Err == nil || !errors.Is(refs[3].Err, errEmptySourceInput) {
+		t.Errorf("refs[2], refs[3] = %+v, %+v, want errors", refs[2], refs[3])
+	}
+	if err == nil || !strings.Contains(err.Error(), "https://bad.example/b") || !strings.Contains(err.Error(), "2 of 4") {
+		t.Errorf("error = %v, want it to list the failed sources", err)
+	}
+}
+
+func TestAddSourceLimitReached(t *testing.T) {
+	c := newFixtureClient("source_limit.txt")
+	_, err := c.AddSourceFromURL("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "https://example.com/51")
+	if !errors.Is(err, ErrSourceLimitReached) {
+		t.Fatalf("AddSourceFromURL() error = %v, want ErrSourceLimitReached", err)
+	}
+	var limitErr *SourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != 50 {
+		t.Errorf("AddSourceFromURL() error = %#v, want limit 50", err)
+	}
+}
+
+func TestAddSourceRPCError(t *testing.T) {
+	c := newFixtureClient("add_source_invalid.txt")
+	_, err := c.AddSourceFromURL("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "https://example.com/missing")
+	var rpcErr *batchexecute.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("AddSourceFromURL() error = %v, want *batchexecute.RPCError", err)
+	}
+	if rpcErr.Code != 3 || rpcErr.Message != "The URL could not be fetched." {
+		t.Errorf("AddSourceFromURL() error = %+v, want code 3 with the server message", rpcErr)
+	}
+	if errors.Is(err, ErrSourceLimitReached) {
+		t.Errorf("AddSourceFromURL() error = %v, want it not to be a source limit error", err)
+	}
+}
+
+func TestAddSourceQuotaExceeded(t *testing.T) {
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		return "", statusReply(8)
+	})
+	_, err := c.AddSourceFromURL("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "https://example.com/page")
+	var rpcErr *batchexecute.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != 8 {
+		t.Fatalf("AddSourceFromURL() error = %v, want *batchexecute.RPCError with code 8", err)
+	}
+	if errors.Is(err, ErrSourceLimitReached) {
+		t.Errorf("AddSourceFromURL() error = %v, want it not to be a source limit error", err)
+	}
+}
+
+func TestSourceLimitError(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		want  error
+	}{
+		{"success", `["wrb.fr","izAoDd","[]",null,null,null,"generic"]`, nil},
+		{"other error", `["wrb.fr","izAoDd",null,null,null,[3],"generic"]`, nil},
+		{"exhausted without details", `["wrb.fr","izAoDd",null,null,null,[8],"generic"]`, nil},
+		{"quota", `["wrb.fr","izAoDd",null,null,null,[8,null,["Quota exceeded for requests per day"]],"generic"]`, nil},
+		{"limit message", `["wrb.fr","izAoDd",null,null,null,[9,null,["Maximum of 300 sources per notebook"]],"generic"]`, &SourceLimitError{Limit: 300}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var frame []interface{}
+			if err := json.Unmarshal([]byte(tt.frame), &frame); err != nil {
+				t.Fatal(err)
+			}
+			got := sourceLimitError(frame)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("sourceLimitError() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSuggestedQuestions(t *testing.T) {
+	tests := []struct {
+		name string
+		resp string
+		want []string
+	}{
+		{"empty", ``, []string{}},
+		{"summary only", `["These sources cover Go tooling."]`, []string{}},
+		{
+			name: "structured suggestions",
+			resp: `["Summary.",[[["How do modules work?","Explain modules"],["What is a workspace?","Explain workspaces"]]]]`,
+			want: []string{"How do modules work?", "What is a workspace?"},
+		},
+		{
+			name: "questions in guide text",
+			resp: `["Summary.\n\n• Why use table-driven tests?"]`,
+			want: []string{"Why use table-driven tests?"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, suggestedQuestions(json.RawMessage(tt.resp))); diff != "" {
+				t.Errorf("suggestedQuestions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOutlineTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []OutlineNode
+	}{
+		{"empty", "", nil},
+		{
+			name: "markdown headings and bullets",
+			content: "# Go Tooling\n" +
+				"## Modules\n" +
+				"- go.mod basics\n" +
+				"  - **Version selection**\n" +
+				"- Workspaces\n" +
+				"## Testing\n" +
+				"- Table-driven tests\n",
+			want: []OutlineNode{{Title: "Go Tooling", Children: []OutlineNode{
+				{Title: "Modules", Children: []OutlineNode{
+					{Title: "go.mod basics", Children: []OutlineNode{{Title: "Version selection"}}},
+					{Title: "Workspaces"},
+				}},
+				{Title: "Testing", Children: []OutlineNode{{Title: "Table-driven tests"}}},
+			}}},
+		},
+		{
+			name: "dotted numbering",
+			content: "1. Introduction\r\n" +
+				"1.1 Background\r\n" +
+				"1.2 Scope\r\n" +
+				"2. Findings\r\n",
+			want: []OutlineNode{
+				{Title: "Introduction", Children: []OutlineNode{{Title: "Background"}, {Title: "Scope"}}},
+				{Title: "Findings"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := OutlineTree(&pb.GenerateOutlineResponse{Content: tt.content})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("OutlineTree() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithFreshnessTimeout(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("freshness request was not bounded")
+		}
+	})
+	c := New("token", "cookies",
+		WithFreshnessTimeout(50*time.Millisecond),
+		WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport})),
+	)
+
+	start := time.Now()
+	result, err := c.CheckSourceFreshness("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "src-1")
+	if err != nil {
+		t.Fatalf("CheckSourceFreshness() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("CheckSourceFreshness() took %s, want it bounded by the timeout", elapsed)
+	}
+	if result.Status != pb.SourceSettings_SOURCE_STATUS_ERROR || !strings.Contains(result.Message, "deadline") {
+		t.Errorf("result = %+v, want a deadline error", result)
+	}
+}
+
+func TestAddSourceFromFileWithLanguage(t *testing.T) {
+	var gotLang, gotReq string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		gotLang = req.Header.Get("Accept-Language")
+		gotReq = req.PostForm.Get("f.req")
+		return rpcResponse(req, rpc.RPCAddSources, `[[["src-1"]]]`), nil
+	})
+	c := New("token", "cookies", WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport})))
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("短い文書です。"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := c.AddSourceFromFileWithLanguage("proj-1", path, "ja")
+	if err != nil {
+		t.Fatalf("AddSourceFromFileWithLanguage() error = %v", err)
+	}
+	if id != "src-1" {
+		t.Errorf("source ID = %q, want %q", id, "src-1")
+	}
+	if gotLang != "ja" {
+		t.Errorf("Accept-Language = %q, want %q", gotLang, "ja")
+	}
+	if !strings.Contains(gotReq, "短い文書です。") {
+		t.Errorf("f.req = %s, want file content", gotReq)
+	}
+
+	if _, err := c.AddSourceFromFileWithLanguage("proj-1", path, ""); err != nil {
+		t.Fatalf("AddSourceFromFileWithLanguage() error = %v", err)
+	}
+	if gotLang == "ja" {
+		t.Errorf("Accept-Language = %q without a language, want the default", gotLang)
+	}
+}
+
+func TestAppendToNote(t *testing.T) {
+	var update []interface{}
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCGetNotes:
+			return `[[["note-1",["note-1","first line",[1],null,"Reading notes"]],["note-2",["note-2","no title"]]]]`, nil
+		case rpc.RPCMutateNote:
+			update = args[2].([]interface{})[0].([]interface{})[0].([]interface{})
+			return `[["note-1"]]`, nil
+		}
+		t.Errorf("unexpected RPC %s", id)
+		return "", nil
+	})
+
+	if _, err := c.AppendToNote("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "note-1", "second line"); err != nil {
+		t.Fatalf("AppendToNote() error = %v", err)
+	}
+	want := []interface{}{"first line\nsecond line", "Reading notes", []interface{}{}}
+	if diff := cmp.Diff(want, update); diff != "" {
+		t.Errorf("MutateNote update mismatch (-want +got):\n%s", diff)
+	}
+
+	update = nil
+	if _, err := c.AppendToNote("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "note-2", "more"); err == nil {
+		t.Error("AppendToNote() of a note without a title slot succeeded, want an error")
+	}
+	if update != nil {
+		t.Errorf("MutateNote sent %v, want nothing written", update)
+	}
+}
+
+func TestAppendToNoteWithRetry(t *testing.T) {
+	const noteID = "note-1"
+	content := "first line"
+	var (
+		mutates int
+		title   string
+	)
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCGetNotes:
+			notes, _ := json.Marshal([]interface{}{[]interface{}{
+				[]interface{}{noteID, []interface{}{noteID, content, []int{1}, nil, "Title"}},
+			}})
+			return string(notes), nil
+		case rpc.RPCMutateNote:
+			update := args[2].([]interface{})[0].([]interface{})[0].([]interface{})
+			content, title = update[0].(string), update[1].(string)
+			mutates++
+			if mutates == 1 {
+				// The write is applied but the reply is lost.
+				return "", fmt.Errorf("simulated timeout")
+			}
+			return `[["` + noteID + `"]]`, nil
+		}
+		t.Errorf("unexpected RPC %s", id)
+		return "", nil
+	})
+
+	if _, err := c.AppendToNoteWithRetry("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", noteID, "second line", 3); err != nil {
+		t.Fatalf("AppendToNoteWithRetry() error = %v", err)
+	}
+	if want := "first line\nsecond line"; content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	if mutates != 1 {
+		t.Errorf("MutateNote sent %d times, want 1", mutates)
+	}
+	if title != "Title" {
+		t.Errorf("MutateNote title = %q, want the existing title", title)
+	}
+}
+
+func TestEnableDisableSources(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	sourceIDs := []string{"0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0", "1a2b3c4d-5e6f-7081-92a3-b4c5d6e7f809"}
+	var sent []interface{}
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id != rpc.RPCActOnSources {
+			t.Errorf("unexpected RPC %s", id)
+		}
+		sent = args
+		return "[]", nil
+	})
+
+	tests := []struct {
+		name   string
+		call   func(string, []string) error
+		action string
+	}{
+		{"enable", c.EnableSources, "enable"},
+		{"disable", c.DisableSources, "disable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = nil
+			if err := tt.call(projectID, sourceIDs); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			want := []interface{}{projectID, tt.action, []interface{}{sourceIDs[0], sourceIDs[1]}}
+			if fmt.Sprint(sent) != fmt.Sprint(want) {
+				t.Errorf("ActOnSources args = %v, want %v", sent, want)
+			}
+		})
+	}
+
+	sent = nil
+	if err := c.ActOnSources(projectID, "archive", sourceIDs); !errors.Is(err, ErrUnknownSourceAction) {
+		t.Errorf("ActOnSources(%q) error = %v, want ErrUnknownSourceAction", "archive", err)
+	}
+	if sent != nil {
+		t.Errorf("unknown action sent: %v", sent)
+	}
+}
+
+func TestDeleteProjectsConfirmation(t *testing.T) {
+	a, b := "ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0"
+	var deletes int
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCListRecentlyViewedProjects:
+			return `[[["Research",[],"` + a + `","📚"],["Scratch",[],"` + b + `","📝"]]]`, nil
+		case rpc.RPCDeleteProjects:
+			deletes++
+			return "[]", nil
+		}
+		t.Errorf("unexpected RPC %s", id)
+		return "", nil
+	})
+
+	for _, titles := range [][]string{nil, {"Research"}, {"Scratch", "Research"}} {
+		if err := c.DeleteProjects([]string{a, b}, titles); !errors.Is(err, ErrDeleteNotConfirmed) {
+			t.Errorf("DeleteProjects() with titles %q error = %v, want ErrDeleteNotConfirmed", titles, err)
+		}
+	}
+	if err := c.DeleteProjects([]string{"0a1b2c3d-0000-0000-0000-000000000000"}, []string{"Research"}); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("DeleteProjects() of unknown project error = %v, want ErrProjectNotFound", err)
+	}
+	if deletes != 0 {
+		t.Fatalf("%d deletes sent without confirmation, want 0", deletes)
+	}
+
+	if err := c.DeleteProjects([]string{a, b}, []string{"Research", "Scratch"}); err != nil {
+		t.Fatalf("DeleteProjects() error = %v", err)
+	}
+	if deletes != 1 {
+		t.Errorf("%d deletes sent, want 1", deletes)
+	}
+}
+
+func TestDeleteProjectsMatching(t *testing.T) {
+	a, b := "ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0"
+	var deleted []interface{}
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCListRecentlyViewedProjects:
+			return `[[["Research",[],"` + a + `","📚"],["Scratch",[],"` + b + `","📝"]]]`, nil
+		case rpc.RPCDeleteProjects:
+			deleted = args
+			return "[]", nil
+		}
+		t.Errorf("unexpected RPC %s", id)
+		return "", nil
+	})
+
+	if _, err := c.DeleteProjectsMatching(func(p *Notebook) bool { return p.GetTitle() == "Drafts" }); err == nil {
+		t.Error("DeleteProjectsMatching() matching nothing succeeded, want an error")
+	}
+	if deleted != nil {
+		t.Fatalf("delete sent when nothing matched: %v", deleted)
+	}
+
+	ids, err := c.DeleteProjectsMatching(func(p *Notebook) bool { return p.GetTitle() == "Scratch" })
+	if err != nil {
+		t.Fatalf("DeleteProjectsMatching() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != b {
+		t.Errorf("DeleteProjectsMatching() = %v, want [%s]", ids, b)
+	}
+	if got, want := fmt.Sprint(deleted), fmt.Sprint([]interface{}{[]interface{}{b}}); got != want {
+		t.Errorf("DeleteProjects args = %s, want %s", got, want)
+	}
+}
+
+func TestProjectSetters(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	var sent []interface{}
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id != rpc.RPCMutateProject {
+			t.Errorf("unexpected RPC %s", id)
+		}
+		sent = args
+		return `["Papers",[],"` + projectID + `","🧪"]`, nil
+	})
+
+	tests := []struct {
+		set  func() (*Notebook, error)
+		want string
+	}{
+		{func() (*Notebook, error) { return c.SetProjectTitle(projectID, "Papers") }, `{"title":"Papers"}`},
+		{func() (*Notebook, error) { return c.SetProjectEmoji(projectID, "🧪") }, `{"emoji":"🧪"}`},
+	}
+	for _, tt := range tests {
+		if _, err := tt.set(); err != nil {
+			t.Fatalf("setter error = %v", err)
+		}
+		if len(sent) != 2 || sent[0] != projectID {
+			t.Fatalf("MutateProject args = %v, want project ID and updates", sent)
+		}
+		if got, _ := json.Marshal(sent[1]); string(got) != tt.want {
+			t.Errorf("MutateProject updates = %s, want only %s", got, tt.want)
+		}
+	}
+}
+
+func TestSourceIDFromResponse(t *testing.T) {
+	tests := []struct {
+		resp       string
+		wantID     string
+		wantFormat int
+		wantErr    string
+	}{
+		{resp: `[[[["src-1","x"]]]]`, wantID: "src-1", wantFormat: 1},
+		{resp: `[[["src-2"]]]`, wantID: "src-2", wantFormat: 2},
+		{resp: `[["src-3",null]]`, wantID: "src-3", wantFormat: 3},
+		{resp: `[[[[["src-4"]],"Title",[null,12]]]]`, wantID: "src-4", wantFormat: 4},
+		{resp: `[[[null,["src-5"]]]]`, wantErr: "array(1) > array(1) > array(2) > null"},
+		{resp: `[[]]`, wantErr: "array(1) > array(0)"},
+	}
+	for _, tt := range tests {
+		id, format, err := sourceIDFromResponse(json.RawMessage(tt.resp))
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+				t.Errorf("sourceIDFromResponse(%s) error = %v, want shape %q", tt.resp, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || id != tt.wantID || format != tt.wantFormat {
+			t.Errorf("sourceIDFromResponse(%s) = %q, %d, %v; want %q, %d", tt.resp, id, format, err, tt.wantID, tt.wantFormat)
+		}
+	}
+}
+
+func TestMutateSourceInProject(t *testing.T) {
+	var sourcePath string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sourcePath = req.URL.Query().Get("source-path")
+		return rpcResponse(req, rpc.RPCMutateSource, `[[["src-1"]],"Renamed"]`), nil
+	})
+	c := New("token", "cookies", WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport})))
+
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	source, err := c.MutateSourceInProject(projectID, "src-1", &pb.Source{Title: "Renamed"})
+	if err != nil {
+		t.Fatalf("MutateSourceInProject() error = %v", err)
+	}
+	if source.GetTitle() != "Renamed" {
+		t.Errorf("title = %q, want %q", source.GetTitle(), "Renamed")
+	}
+	if want := "/notebook/" + projectID; sourcePath != want {
+		t.Errorf("source-path = %q, want %q", sourcePath, want)
+	}
+
+	if _, err := c.MutateSource("src-1", &pb.Source{Title: "Renamed"}); err != nil {
+		t.Fatalf("MutateSource() error = %v", err)
+	}
+	if sourcePath != "/" {
+		t.Errorf("source-path without project = %q, want %q", sourcePath, "/")
+	}
+}
+
+func TestSyncDriveSources(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	project := `["Drive notes",[` +
+		`[["src-stale"],"Stale doc",[null,null,null,null,3]],` +
+		`[["src-fresh"],"Fresh doc",[null,null,null,null,3]],` +
+		`[["src-web"],"Web page",[null,null,null,null,7]]` +
+		`],"` + projectID + `","📚"]`
+	freshness := map[string]int{"src-stale": 2, "src-fresh": 1}
+	refreshed := make(map[string]int)
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCGetProject:
+			return project, nil
+		case rpc.RPCCheckSourceFreshness:
+			for src, code := range freshness {
+				if strings.Contains(fmt.Sprint(args), src) {
+					return "", statusReply(code)
+				}
+			}
+			return "", statusReply(0)
+		}
+		for _, src := range []string{"src-stale", "src-fresh", "src-web"} {
+			if strings.Contains(fmt.Sprint(args), src) {
+				refreshed[src]++
+			}
+		}
+		return "[]", nil
+	})
+
+	results, err := c.SyncDriveSources(projectID)
+	if err != nil {
+		t.Fatalf("SyncDriveSources() error = %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("results = %v, want only src-stale", results)
+	}
+	if err, ok := results["src-stale"]; !ok || err != nil {
+		t.Errorf("results[src-stale] = %v, %v; want nil, true", err, ok)
+	}
+	if refreshed["src-stale"] == 0 || refreshed["src-fresh"] != 0 || refreshed["src-web"] != 0 {
+		t.Errorf("sync calls per source = %v, want only src-stale", refreshed)
+	}
+}
+
+func TestMarshalResult(t *testing.T) {
+	b, err := MarshalResult(&pb.Project{Title: "Reading list", ProjectId: "proj-1", Emoji: "📚"})
+	if err != nil {
+		t.Fatalf("MarshalResult() error = %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, b)
+	}
+	want := map[string]string{"title": "Reading list", "projectId": "proj-1", "emoji": "📚"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MarshalResult() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNoteCitations(t *testing.T) {
+	project := &Notebook{Sources: []*pb.Source{
+		{SourceId: &pb.SourceId{SourceId: "src-1"}},
+		{SourceId: &pb.SourceId{SourceId: "src-2"}},
+	}}
+	tests := []struct {
+		name string
+		note string
+		want []string
+	}{
+		{"cited", `["note-1",["note-1","Compare",[2],[["src-2"],["src-1"],["src-2"]],"Comparison"]]`, []string{"src-2", "src-1"}},
+		{"uncited", `["note-2",["note-2","Todo",[1],null,"Todo"]]`, []string{}},
+		{"unknown source", `["note-3",["note-3","Old",[2],[["src-gone"]],"Old"]]`, []string{}},
+		{"bare", `["note-4"]`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var noteArr []interface{}
+			if err := json.Unmarshal([]byte(tt.note), &noteArr); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, noteCitations(noteArr, project)); diff != "" {
+				t.Errorf("noteCitations() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	c := fakeRPC(t, map[string]string{
+		rpc.RPCGetProject: `["Papers",[[["src-1"],"One"],[["src-2"],"Two"]],"` + projectID + `","📚"]`,
+		rpc.RPCGetNotes:   `[[` + tests[0].note + `]]`,
+	})
+	got, err := c.NoteCitations(projectID, "note-1")
+	if err != nil {
+		t.Fatalf("NoteCitations() error = %v", err)
+	}
+	if diff := cmp.Diff(tests[0].want, got); diff != "" {
+		t.Errorf("NoteCitations() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSourceReferences(t *testing.T) {
+	notes := []interface{}{[]interface{}{
+		[]interface{}{"note-1", []interface{}{"note-1", "Summary of the paper", []int{1}, []interface{}{[]interface{}{"src-1"}}, "Summary"}},
+		[]interface{}{"note-2", []interface{}{"note-2", "Unrelated", []int{1}, nil, "Todo"}},
+		[]interface{}{"note-3", []interface{}{"note-3", "Compare", []int{2}, []interface{}{[]interface{}{"src-2"}, []interface{}{"src-1"}}, "Comparison"}},
+	}}
+	body, _ := json.Marshal(notes)
+	c := fakeRPC(t, map[string]string{rpc.RPCGetNotes: string(body)})
+
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	refs, err := c.SourceReferences(projectID, "src-1")
+	if err != nil {
+		t.Fatalf("SourceReferences() error = %v", err)
+	}
+	var got []string
+	for _, n := range refs {
+		got = append(got, n.GetSourceId().GetSourceId()+" "+n.GetTitle())
+	}
+	if diff := cmp.Diff([]string{"note-1 Summary", "note-3 Comparison"}, got); diff != "" {
+		t.Errorf("SourceReferences() mismatch (-want +got):\n%s", diff)
+	}
+
+	refs, err = c.SourceReferences(projectID, "src-9")
+	if err != nil {
+		t.Fatalf("SourceReferences() error = %v", err)
+	}
+	if refs == nil || len(refs) != 0 {
+		t.Errorf("SourceReferences() for uncited source = %#v, want empty slice", refs)
+	}
+}
+
+func TestAddSourcesFromURLsWithOptions(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if strings.Contains(fmt.Sprint(args), "slow.example") {
+			<-release
+		}
+		return `[[["src-ok"]]]`, nil
+	})
+
+	urls := []string{"https://example.com/a", "https://slow.example/b", "https://example.com/c"}
+	refs, err := c.AddSourcesFromURLsWithOptions("proj-1", urls, AddSourcesOptions{ItemTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("AddSourcesFromURLsWithOptions() error = %v", err)
+	}
+	for i, ref := range refs {
+		if ref.URL != urls[i] {
+			t.Errorf("refs[%d].URL = %q, want %q", i, ref.URL, urls[i])
+		}
+		slow := strings.Contains(urls[i], "slow")
+		if slow && !errors.Is(ref.Err, ErrSourceTimeout) {
+			t.Errorf("refs[%d].Err = %v, want ErrSourceTimeout", i, ref.Err)
+		}
+		if !slow && (ref.Err != nil || ref.SourceID != "src-ok") {
+			t.Errorf("refs[%d] = %q, %v; want src-ok", i, ref.SourceID, ref.Err)
+		}
+	}
+
+	urls = []string{"https://slow.example/1", "https://slow.example/2", "https://slow.example/3", "https://slow.example/4", "https://example.com/5"}
+	start := time.Now()
+	refs, err = c.AddSourcesFromURLsWithOptions("proj-1", urls, AddSourcesOptions{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("AddSourcesFromURLsWithOptions() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch took %s, want it bounded by the deadline", elapsed)
+	}
+	for i, ref := range refs {
+		if !errors.Is(ref.Err, ErrSourceTimeout) {
+			t.Errorf("refs[%d].Err = %v, want ErrSourceTimeout", i, ref.Err)
+		}
+	}
+}
+
+func TestAddConcurrentlyBoundsAbandoned(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var running, peak, started int
+	refs := addConcurrently(3*addSourceWorkers, AddSourcesOptions{ItemTimeout: 20 * time.Millisecond}, func(i int) *SourceRef {
+		mu.Lock()
+		running++
+		started++
+		peak = max(peak, running)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &SourceRef{SourceID: "src"}
+	})
+	close(release)
+
+	for i, ref := range refs {
+		if !errors.Is(ref.Err, ErrSourceTimeout) {
+			t.Errorf("refs[%d].Err = %v, want ErrSourceTimeout", i, ref.Err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peak > addSourceWorkers || started > addSourceWorkers {
+		t.Errorf("%d adds started, %d at once, want at most %d while the first are stuck", started, peak, addSourceWorkers)
+	}
+}
+
+func TestParseShareAudioResponse(t *testing.T) {
+	tests := []struct {
+		resp    string
+		want    *ShareAudioResult
+		wantErr string
+	}{
+		{
+			resp: `[["https://notebooklm.google.com/notebook/abc/audio","share-1"]]`,
+			want: &ShareAudioResult{ShareURL: "https://notebooklm.google.com/notebook/abc/audio", ShareID: "share-1"},
+		},
+		{
+			resp: `[["https://notebooklm.google.com/notebook/abc/audio"]]`,
+			want: &ShareAudioResult{ShareURL: "https://notebooklm.google.com/notebook/abc/audio"},
+		},
+		{resp: `[]`, wantErr: "array(0)"},
+		{resp: `[[null,"share-1"]]`, wantErr: "array(1) > array(2) > null"},
+		{resp: `[[["https://nested.example"]]]`, wantErr: "array(1) > array(1) > array(1) > string"},
+	}
+	for _, tt := range tests {
+		got, err := parseShareAudioResponse(json.RawMessage(tt.resp))
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+				t.Errorf("parseShareAudioResponse(%s) error = %v, want shape %q", tt.resp, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseShareAudioResponse(%s) error = %v", tt.resp, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("parseShareAudioResponse(%s) mismatch (-want +got):\n%s", tt.resp, diff)
+		}
+	}
+}
+
+func TestShareNotebook(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	tests := []struct {
+		name     string
+		option   ShareOption
+		resp     string
+		wantArgs string
+		want     *ShareResult
+		wantErr  string
+	}{
+		{
+			name:     "public",
+			option:   SharePublic,
+			resp:     `[["https://notebooklm.google.com/notebook/shared"],[1]]`,
+			wantArgs: `["` + projectID + `",[1]]`,
+			want:     &ShareResult{ShareURL: "https://notebooklm.google.com/notebook/shared", IsPublic: true},
+		},
+		{
+			name:     "server kept notebook private",
+			option:   SharePublic,
+			resp:     `[null,[0]]`,
+			wantArgs: `["` + projectID + `",[1]]`,
+			want:     &ShareResult{ShareURL: "https://notebooklm.google.com/notebook/" + projectID},
+		},
+		{
+			name:     "missing visibility",
+			option:   SharePrivate,
+			resp:     `[["https://notebooklm.google.com/notebook/shared"]]`,
+			wantArgs: `["` + projectID + `",[0]]`,
+			wantErr:  "array(1) > array(1) > string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs string
+			c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+				b, _ := json.Marshal(args)
+				gotArgs = string(b)
+				return tt.resp, nil
+			})
+			got, err := c.ShareNotebook(projectID, tt.option)
+			if gotArgs != tt.wantArgs {
+				t.Errorf("ShareNotebook() args = %s, want %s", gotArgs, tt.wantArgs)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+					t.Errorf("ShareNotebook() error = %v, want shape %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ShareNotebook() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ShareNotebook() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNoteByTitle(t *testing.T) {
+	var deleted string
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCGetNotes:
+			return `[[[["note-1"],"Ideas"],[["note-2"],"Todo"],[["note-3"],"Todo"]]]`, nil
+		case rpc.RPCDeleteNotes:
+			deleted = fmt.Sprint(args)
+		}
+		return "[]", nil
+	})
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+
+	note, err := c.GetNoteByTitle(projectID, "Ideas")
+	if err != nil {
+		t.Fatalf("GetNoteByTitle() error = %v", err)
+	}
+	if got := note.GetSourceId().GetSourceId(); got != "note-1" {
+		t.Errorf("GetNoteByTitle() = %s, want note-1", got)
+	}
+	if _, err := c.GetNoteByTitle(projectID, "Missing"); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("GetNoteByTitle(Missing) error = %v, want ErrNoteNotFound", err)
+	}
+
+	err = c.DeleteNoteByTitle(projectID, "Todo")
+	if !errors.Is(err, ErrAmbiguousNoteTitle) || !strings.Contains(err.Error(), "note-2, note-3") {
+		t.Errorf("DeleteNoteByTitle(Todo) error = %v, want ErrAmbiguousNoteTitle listing both IDs", err)
+	}
+	if deleted != "" {
+		t.Fatalf("ambiguous title deleted a note: %s", deleted)
+	}
+
+	if err := c.DeleteNoteByTitle(projectID, "Ideas"); err != nil {
+		t.Fatalf("DeleteNoteByTitle() error = %v", err)
+	}
+	if !strings.Contains(deleted, "note-1") {
+		t.Errorf("DeleteNotes request = %s, want note-1", deleted)
+	}
+}
+
+func TestWithSkipDisabledSources(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	project := `["Sweep",[` +
+		`[["src-on"],"On",[null,null,null,null,3],[null,1]],` +
+		`[["src-off"],"Off",[null,null,null,null,3],[null,2]]` +
+		`],"` + projectID + `","📚"]`
+	var checked []string
+	answer := func(id string, args []interface{}) (string, error) {
+		if id != rpc.RPCCheckSourceFreshness {
+			return project, nil
+		}
+		for _, src := range []string{"src-on", "src-off"} {
+			if strings.Contains(fmt.Sprint(args), src) {
+				checked = append(checked, src)
+			}
+		}
+		return "", statusReply(1)
+	}
+
+	for _, skip := range []bool{false, true} {
+		checked = nil
+		c := fakeRPCFunc(t, answer, WithSkipDisabledSources(skip))
+
+		want := []string{"src-on", "src-off"}
+		if skip {
+			want = want[:1]
+		}
+
+		statuses, err := c.SourceStatuses(projectID)
+		if err != nil {
+			t.Fatalf("SourceStatuses() error = %v", err)
+		}
+		if len(statuses) != len(want) {
+			t.Errorf("skip=%v: SourceStatuses() = %v, want %v", skip, statuses, want)
+		}
+
+		entries, err := c.SourceStatusList(projectID, nil)
+		if err != nil {
+			t.Fatalf("SourceStatusList() error = %v", err)
+		}
+		var listed []string
+		for _, e := range entries {
+			listed = append(listed, e.SourceID)
+		}
+		if diff := cmp.Diff(want, listed); diff != "" {
+			t.Errorf("skip=%v: SourceStatusList() mismatch (-want +got):\n%s", skip, diff)
+		}
+
+		results, err := c.CheckSourcesFreshness(projectID, []string{"src-on", "src-off"})
+		if err != nil {
+			t.Fatalf("CheckSourcesFreshness() error = %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("skip=%v: CheckSourcesFreshness() returned %d results, want 2", skip, len(results))
+		}
+		if results[0].Skipped || results[1].Skipped != skip {
+			t.Errorf("skip=%v: CheckSourcesFreshness() Skipped = %v, %v; want false, %v", skip, results[0].Skipped, results[1].Skipped, skip)
+		}
+		if diff := cmp.Diff(want, checked); diff != "" {
+			t.Errorf("skip=%v: checked sources mismatch (-want +got):\n%s", skip, diff)
+		}
+	}
+}
+
+func TestCheckSourcesFreshnessErrors(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	failure := errors.New("connection reset")
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if args[0] == "src-3" {
+			return "", failure
+		}
+		return "", statusReply(1)
+	})
+
+	ids := []string{"src-1", "not a source", "src-2", "src-3"}
+	results, err := c.CheckSourcesFreshness(projectID, ids)
+	if err != nil {
+		t.Fatalf("CheckSourcesFreshness() error = %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.SourceID)
+	}
+	if diff := cmp.Diff(ids, got); diff != "" {
+		t.Fatalf("CheckSourcesFreshness() sources mismatch (-want +got):\n%s", diff)
+	}
+	for i, r := range results {
+		if wantErr := i == 1 || i == 3; (r.Err != nil) != wantErr {
+			t.Errorf("result %d (%s) Err = %v, want error: %v", i, r.SourceID, r.Err, wantErr)
+		}
+	}
+	if bad := results[1]; !errors.Is(bad.Err, ErrInvalidSourceID) || bad.Status != pb.SourceSettings_SOURCE_STATUS_ERROR {
+		t.Errorf("invalid source result = %+v, want SOURCE_STATUS_ERROR with ErrInvalidSourceID", bad)
+	}
+	if failed := results[3]; !errors.Is(failed.Err, failure) || failed.Status != pb.SourceSettings_SOURCE_STATUS_ERROR {
+		t.Errorf("failed check result = %+v, want SOURCE_STATUS_ERROR with the RPC error", failed)
+	}
+}
+
+func TestGenerateOutlineForSources(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	project := `["Reading list",[[["src-a"],"Alpha"],[["src-b"],"Beta"]],"` + projectID + `","📚"]`
+	var sent string
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id != rpc.RPCGenerateOutline {
+			return project, nil
+		}
+		b, _ := json.Marshal(args)
+		sent = string(b)
+		return `["Outline"]`, nil
+	})
+
+	if _, err := c.GenerateOutline(projectID); err != nil {
+		t.Fatalf("GenerateOutline() error = %v", err)
+	}
+	if want := `["` + projectID + `"]`; sent != want {
+		t.Errorf("GenerateOutline() sent %s, want %s", sent, want)
+	}
+
+	if _, err := c.GenerateOutlineForSources(projectID, []string{"src-b"}); err != nil {
+		t.Fatalf("GenerateOutlineForSources() error = %v", err)
+	}
+	if want := `["` + projectID + `",[["src-b"]]]`; sent != want {
+		t.Errorf("GenerateOutlineForSources() sent %s, want %s", sent, want)
+	}
+
+	sent = ""
+	_, err := c.GenerateOutlineForSources(projectID, []string{"src-z"})
+	if !errors.Is(err, ErrSourceNotInProject) {
+		t.Errorf("GenerateOutlineForSources() with unknown source error = %v, want %v", err, ErrSourceNotInProject)
+	}
+	if sent != "" {
+		t.Errorf("GenerateOutlineForSources() with unknown source sent %s", sent)
+	}
+}
+
+func TestWithCredentials(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	var mu sync.Mutex
+	sent := map[string]string{}
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		sent[req.PostForm.Get("at")] = req.Header.Get("Cookie")
+		mu.Unlock()
+		return rpcResponse(req, rpc.RPCGetProject, `["Reading list",[],"`+projectID+`","📚"]`), nil
+	})
+	c := New("token-a", "SID=a", WithRPCOptions(batchexecute.WithHTTPClient(&http.Client{Transport: transport})))
+	other := c.WithCredentials("token-b", "SID=b")
+
+	for _, client := range []*Client{c, other} {
+		if _, err := client.GetProject(projectID); err != nil {
+			t.Fatalf("GetProject() error = %v", err)
+		}
+	}
+	want := map[string]string{"token-a": "SID=a", "token-b": "SID=b"}
+	if diff := cmp.Diff(want, sent); diff != "" {
+		t.Errorf("credentials sent over the shared transport (-want +got):\n%s", diff)
+	}
+}
+
+func TestClassifyURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want URLKind
+	}{
+		{"https://example.com/article", URLKindWebPage},
+		{"https://arxiv.org/pdf/2401.00001v1.pdf", URLKindPDF},
+		{"https://example.com/Report.PDF?download=1", URLKindPDF},
+		{"https://docs.google.com/document/d/abc123/edit", URLKindWebPage},
+		{"https://drive.google.com/file/d/abc123/view", URLKindWebPage},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", URLKindYouTube},
+		{"https://youtu.be/dQw4w9WgXcQ", URLKindYouTube},
+	}
+	for _, tt := range tests {
+		if got := ClassifyURL(tt.url); got != tt.want {
+			t.Errorf("ClassifyURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestAddSourceFromURLPDF(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	pdf := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n")
+	body := pdf
+	var sent string
+	rpcs := fakeRPCRoundTrip(func(id string, args []interface{}) (string, error) {
+		b, _ := json.Marshal(args)
+		sent = string(b)
+		return `[[["src-pdf"]]]`, nil
+	})
+	// The PDF is served by the configured transport, not the network.
+	c := fakeRPCTransport(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host != "papers.example" {
+			return rpcs(req)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": {"application/octet-stream"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	}))
+
+	const pdfURL = "https://papers.example/papers/paper.pdf"
+	id, err := c.AddSourceFromURL(projectID, pdfURL)
+	if err != nil {
+		t.Fatalf("AddSourceFromURL() error = %v", err)
+	}
+	if id != "src-pdf" {
+		t.Errorf("AddSourceFromURL() = %q, want src-pdf", id)
+	}
+	encoded := base64.StdEncoding.EncodeToString(pdf)
+	if !strings.Contains(sent, encoded) || !strings.Contains(sent, "paper.pdf") {
+		t.Errorf("f.req = %s, want the uploaded PDF named paper.pdf", sent)
+	}
+	if strings.Contains(sent, pdfURL) {
+		t.Errorf("f.req = %s, want no URL entry", sent)
+	}
+
+	// A landing page behind a .pdf link is added as a web page.
+	body = []byte("<!DOCTYPE html><title>Paper</title>")
+	if _, err := c.AddSourceFromURL(projectID, pdfURL); err != nil {
+		t.Fatalf("AddSourceFromURL() of an HTML page error = %v", err)
+	}
+	if !strings.Contains(sent, pdfURL) {
+		t.Errorf("f.req = %s, want a URL entry for %s", sent, pdfURL)
+	}
+}
+
+func TestOperation(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	var polls int
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCGetAudioOverview:
+			polls++
+			ready := polls >= 3
+			return fmt.Sprintf(`[null,null,[3,null,"audio-1","Overview",null,%t]]`, ready), nil
+		case rpc.RPCLoadSource:
+			return `[[["src-1"]],"Doc",null,[null,3]]`, nil
+		}
+		return "[]", nil
+	})
+
+	op, err := c.StartAudioOverview(projectID, AudioOverviewOptions{Instructions: "be brief"})
+	if err != nil {
+		t.Fatalf("StartAudioOverview() error = %v", err)
+	}
+	op.poll = PollOptions{Initial: time.Millisecond}
+	if state, err := op.Status(); err != nil || state != OperationRunning {
+		t.Errorf("Status() = %v, %v; want running, nil", state, err)
+	}
+	if err := op.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("Wait() polled %d times, want 3", polls)
+	}
+	if err := op.Cancel(context.Background()); !errors.Is(err, ErrAudioAlreadyReady) {
+		t.Errorf("Cancel() after completion error = %v, want %v", err, ErrAudioAlreadyReady)
+	}
+
+	none := fakeRPC(t, map[string]string{rpc.RPCGetAudioOverview: `[null,null,null]`})
+	if err := none.CancelAudioOverview(projectID); !errors.Is(err, ErrNoAudioOverview) {
+		t.Errorf("CancelAudioOverview() without an overview error = %v, want %v", err, ErrNoAudioOverview)
+	}
+
+	op, err = c.StartSourceRefresh(projectID, "src-1")
+	if err != nil {
+		t.Fatalf("StartSourceRefresh() error = %v", err)
+	}
+	if state, err := op.Status(); state != OperationFailed || !errors.Is(err, ErrSourceFailed) {
+		t.Errorf("Status() = %v, %v; want failed, %v", state, err, ErrSourceFailed)
+	}
+	if err := op.Cancel(context.Background()); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Cancel() error = %v, want %v", err, ErrUnsupported)
+	}
+
+	pending := &Operation{
+		status: func() (OperationState, error) { return OperationRunning, nil },
+		poll:   PollOptions{Initial: time.Millisecond},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pending.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWithSourceIDRecovery(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	// An earlier copy titled Notes exists, and even sorts as more recently
+	// modified than the one being added; it must not be picked.
+	var added bool
+	answer := func(id string, args []interface{}) (string, error) {
+		if id == rpc.RPCAddSources {
+			added = true
+			return `[[]]`, nil
+		}
+		sources := `[["src-old"],"Notes",[null,null,[1730000000,0]]],` +
+			`[["src-other"],"Other",[null,null,[1730000000,0]]]`
+		if added {
+			sources += `,[["src-new"],"Notes",[null,null,[1728034802,0]]]`
+		}
+		return `["Reading list",[` + sources + `],"` + projectID + `","📚"]`, nil
+	}
+
+	strict := fakeRPCFunc(t, answer)
+	if _, err := strict.AddSourceFromText(projectID, "body", "Notes"); err == nil {
+		t.Error("AddSourceFromText() without recovery succeeded, want extraction error")
+	}
+
+	fastPoll := WithSourceIDRecoveryPoll(20*time.Millisecond, &PollOptions{Initial: time.Millisecond})
+	c := fakeRPCFunc(t, answer, WithSourceIDRecovery(true), fastPoll)
+	added = false
+	id, err := c.AddSourceFromText(projectID, "body", "Notes")
+	if err != nil {
+		t.Fatalf("AddSourceFromText() error = %v", err)
+	}
+	if id != "src-new" {
+		t.Errorf("AddSourceFromText() = %q, want src-new", id)
+	}
+	if _, err := c.AddSourceFromText(projectID, "body", "Missing"); err == nil || !strings.Contains(err.Error(), `no source titled "Missing"`) {
+		t.Errorf("AddSourceFromText() with unknown title error = %v, want recovery failure", err)
+	}
+
+	// A web page is titled after the page, not its URL, and shows up in
+	// the project only after a while.
+	var reads int
+	c = fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id == rpc.RPCAddSources {
+			return `[[]]`, nil
+		}
+		reads++
+		sources := `[["src-other"],"https://go.dev/blog",[null,null,[1730000000,0]]]`
+		if reads >= 3 {
+			sources += `,[["src-web"],"The Go Blog",[null,1200,[1728034802,0],null,7,null,null,["https://go.dev/blog"]]]`
+		}
+		return `[["Reading list",[` + sources + `],"` + projectID + `","📚"]]`, nil
+	}, WithSourceIDRecovery(true), WithSourceIDRecoveryPoll(time.Second, &PollOptions{Initial: time.Millisecond}))
+	id, err = c.AddSourceFromURL(projectID, "https://go.dev/blog")
+	if err != nil {
+		t.Fatalf("AddSourceFromURL() error = %v", err)
+	}
+	if id != "src-web" || reads != 3 {
+		t.Errorf("AddSourceFromURL() = %q after %d reads, want src-web after 3", id, reads)
+	}
+}
+
+func TestAddYouTubeSourceEmptyResponse(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	video := func(id string, modified int) string {
+		return fmt.Sprintf(`[["%s"],"Talk",[null,900,[%d,0],null,9,["https://www.youtube.com/watch?v=hkhDdcM5V94","hkhDdcM5V94"]]]`, id, modified)
+	}
+
+	tests := []struct {
+		name     string
+		recovery bool
+		want     string
+		reads    int // GetProject calls ahead of the add
+	}{
+		{name: "snapshot", recovery: true, want: "yt-new", reads: 1},
+		{name: "newest", want: "yt-old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var added bool
+			var reads int
+			project := fakeRPCRoundTrip(func(id string, args []interface{}) (string, error) {
+				if !added {
+					reads++
+				}
+				sources := video("yt-old", 1730000000)
+				if added {
+					sources += "," + video("yt-new", 1728034802)
+				}
+				return `["Reading list",[` + sources + `],"` + projectID + `","📚"]`, nil
+			})
+			// The add is answered with a null data slot, as the server does.
+			c := fakeRPCTransport(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Query().Get("rpcids") == rpc.RPCAddSources {
+					added = true
+					return frameResponse(req, []interface{}{"wrb.fr", rpc.RPCAddSources, nil, nil, nil, nil, "generic"}), nil
+				}
+				return project(req)
+			}), WithSourceIDRecovery(tt.recovery))
+
+			id, err := c.AddYouTubeSource(projectID, "hkhDdcM5V94")
+			if err != nil {
+				t.Fatalf("AddYouTubeSource() error = %v", err)
+			}
+			if id != tt.want {
+				t.Errorf("AddYouTubeSource() = %q, want %q", id, tt.want)
+			}
+			if reads != tt.reads {
+				t.Errorf("%d project reads before the add, want %d", reads, tt.reads)
+			}
+		})
+	}
+}
+
+func TestExternalIDTitle(t *testing.T) {
+	title, err := ExternalIDTitle("doc-42", "Quarterly report")
+	if err != nil {
+		t.Fatalf("ExternalIDTitle() error = %v", err)
+	}
+	if want := "[ext:doc-42] Quarterly report"; title != want {
+		t.Errorf("ExternalIDTitle() = %q, want %q", title, want)
+	}
+	id, rest, ok := ParseExternalIDTitle(title)
+	if !ok || id != "doc-42" || rest != "Quarterly report" {
+		t.Errorf("ParseExternalIDTitle(%q) = %q, %q, %v; want doc-42, Quarterly report, true", title, id, rest, ok)
+	}
+
+	for _, bad := range []string{"", "has space", "a]b"} {
+		if _, err := ExternalIDTitle(bad, "x"); !errors.Is(err, ErrInvalidExternalID) {
+			t.Errorf("ExternalIDTitle(%q) error = %v, want %v", bad, err, ErrInvalidExternalID)
+		}
+	}
+	for _, plain := range []string{"Quarterly report", "[ext:] empty", "[ext:open"} {
+		if _, _, ok := ParseExternalIDTitle(plain); ok {
+			t.Errorf("ParseExternalIDTitle(%q) ok = true, want false", plain)
+		}
+	}
+}
+
+func TestSourceWithExternalID(t *testing.T) {
+	var project pb.Project
+	raw := `["Reading list",[` +
+		`[["src-old"],"[ext:doc-42] Report",[null,null,[1700000000,0]]],` +
+		`[["src-new"],"[ext:doc-42] Report v2",[null,null,[1728034802,0]]],` +
+		`[["src-other"],"[ext:doc-7] Other",[null,null,[1730000000,0]]]` +
+		`],"proj-1","📚"]`
+	if err := beprotojson.Unmarshal([]byte(raw), &project); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := sourceWithExternalID(&project, "doc-42").GetSourceId().GetSourceId(); got != "src-new" {
+		t.Errorf("sourceWithExternalID(doc-42) = %q, want src-new", got)
+	}
+	if got := sourceWithExternalID(&project, "doc-1"); got != nil {
+		t.Errorf("sourceWithExternalID(doc-1) = %v, want nil", got)
+	}
+}
+
+func TestProjectForSource(t *testing.T) {
+	var list pb.ListRecentlyViewedProjectsResponse
+	raw := `[[["A",[[["src-a"],"Alpha"]],"proj-a","📚"],["B",[[["src-b"],"Beta"]],"proj-b","🧪"]]]`
+	if err := beprotojson.Unmarshal([]byte(raw), &list); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got, err := projectForSource(list.Projects, "src-b"); err != nil || got != "proj-b" {
+		t.Errorf("projectForSource(src-b) = %q, %v; want proj-b, nil", got, err)
+	}
+	if _, err := projectForSource(list.Projects, "src-z"); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("projectForSource(src-z) error = %v, want %v", err, ErrSourceNotFound)
+	}
+	if _, err := projectForSource(nil, "src-a"); !errors.Is(err, ErrNoProjects) {
+		t.Errorf("projectForSource() with no projects error = %v, want %v", err, ErrNoProjects)
+	}
+}
+
+func TestProjectListCache(t *testing.T) {
+	const (
+		projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+		sourceID  = "5f2c1a9e-7b3d-4e8f-9a6c-1d2e3f4a5b6c"
+	)
+	var lists int
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		switch id {
+		case rpc.RPCListRecentlyViewedProjects:
+			lists++
+			return `[[["Research",[[["` + sourceID + `"],"Alpha"]],"` + projectID + `","📚"]]]`, nil
+		case rpc.RPCDeleteSources:
+			return "[]", nil
+		}
+		t.Errorf("unexpected RPC %s", id)
+		return "", nil
+	}, WithProjectListCache(50*time.Millisecond))
+
+	projects, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		t.Fatalf("ListRecentlyViewedProjects() error = %v", err)
+	}
+	projects[0].Title = "changed"
+	projects[0].Sources = nil
+
+	projects, err = c.ListRecentlyViewedProjects()
+	if err != nil {
+		t.Fatalf("ListRecentlyViewedProjects() error = %v", err)
+	}
+	if lists != 1 {
+		t.Fatalf("%d list RPCs within the TTL, want 1", lists)
+	}
+	if got := projects[0].GetTitle(); got != "Research" {
+		t.Errorf("cached title = %q after caller edit, want %q", got, "Research")
+	}
+	if got := len(projects[0].GetSources()); got != 1 {
+		t.Errorf("cached project has %d sources after caller edit, want 1", got)
+	}
+
+	if err := c.DeleteSources(projectID, []string{sourceID}); err != nil {
+		t.Fatalf("DeleteSources() error = %v", err)
+	}
+	if _, err := c.ListRecentlyViewedProjects(); err != nil {
+		t.Fatalf("ListRecentlyViewedProjects() error = %v", err)
+	}
+	if lists != 2 {
+		t.Fatalf("%d list RPCs after deleting a source, want 2", lists)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := c.ListRecentlyViewedProjects(); err != nil {
+		t.Fatalf("ListRecentlyViewedProjects() error = %v", err)
+	}
+	if lists != 3 {
+		t.Fatalf("%d list RPCs after the TTL expired, want 3", lists)
+	}
+}
+
+func TestRefreshSourceAndWait(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	tests := []struct {
+		name     string
+		statuses []int // LoadSource statuses in turn; the last one repeats
+		wantErr  error
+	}{
+		{name: "settles", statuses: []int{0, 0, 1}},
+		{name: "fails", statuses: []int{0, 3}, wantErr: ErrSourceFailed},
+		{name: "still processing", statuses: []int{0}, wantErr: ErrSourceProcessing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+				calls = append(calls, id)
+				if id != rpc.RPCLoadSource {
+					return `[[["src-1"]],"Doc",[null,null,[1728034802,0]]]`, nil
+				}
+				loads := 0
+				for _, c := range calls {
+					if c == rpc.RPCLoadSource {
+						loads++
+					}
+				}
+				status := tt.statuses[min(loads, len(tt.statuses))-1]
+				return fmt.Sprintf(`[[["src-1"]],"Doc",[null,null,[1728034802,0]],[null,%d]]`, status), nil
+			})
+
+			source, err := c.RefreshSourceAndWait(projectID, "src-1", 50*time.Millisecond, &PollOptions{Initial: time.Millisecond})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshSourceAndWait() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(calls) == 0 || calls[0] == rpc.RPCLoadSource {
+				t.Errorf("calls = %v, want a refresh before polling", calls)
+			}
+			want := pb.SourceSettings_SourceStatus(tt.statuses[len(tt.statuses)-1])
+			if got := source.GetSettings().GetStatus(); got != want {
+				t.Errorf("RefreshSourceAndWait() status = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRefreshSourceTimestamp(t *testing.T) {
+	const projectID = "ec266e3d-cb7a-4c6d-a34a-f108a55faf52"
+	c := fakeRPCFunc(t, func(id string, args []interface{}) (string, error) {
+		if id == rpc.RPCLoadSource {
+			return `[[["src-1"]],"Doc",[null,null,[1728034802,0]],[null,1]]`, nil
+		}
+		return "[]", nil
+	})
+
+	source, err := c.RefreshSource(projectID, "src-1")
+	if err != nil {
+		t.Fatalf("RefreshSource() error = %v", err)
+	}
+	got := timeOf(source.GetMetadata().GetLastModifiedTime())
+	if want := time.Unix(1728034802, 0); !got.Equal(want) {
+		t.Errorf("RefreshSource() last modified = %v, want %v", got, want)
+	}
+	if id := source.GetSourceId().GetSourceId(); id != "src-1" {
+		t.Errorf("RefreshSource() source ID = %q, want src-1", id)
+	}
+}
+
+func TestAudioOverviewReader(t *testing.T) {
+	audio := []byte("ID3\x04\x00\x00\x00\x00\x00\x00audio frames")
+	r, err := (&AudioOverviewResult{AudioData: base64.StdEncoding.EncodeToString(audio)}).Reader()
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, audio) {
+		t.Errorf("Reader() yielded %q, want %q", got, audio)
+	}
+
+	if _, err := (&AudioOverviewResult{}).Reader(); err == nil {
+		t.Error("Reader() without audio data succeeded, want error")
+	}
+
+	r, err = (&AudioOverviewResult{AudioData: "not base64!"}).Reader()
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("reading malformed audio data succeeded, want error")
+	}
+}
+
+func TestCheckSourceIDs(t *testing.T) {
+	c := fakeRPC(t, nil)
+
+	for _, id := range []string{"", "My Paper.pdf", "src-1\n"} {
+		if _, err := c.LoadSource(id); !errors.Is(err, ErrInvalidSourceID) {
+			t.Errorf("LoadSource(%q) error = %v, want %v", id, err, ErrInvalidSourceID)
+		}
+	}
+	err := c.DeleteSources("ec266e3d-cb7a-4c6d-a34a-f108a55faf52", []string{"src-1", "not an id"})
+	if !errors.Is(err, ErrInvalidSourceID) {
+		t.Errorf("DeleteSources() error = %v, want %v", err, ErrInvalidSourceID)
+	}
+
+	for _, id := range []string{"ec266e3d-cb7a-4c6d-a34a-f108a55faf52", "src_1", "ABC123"} {
+		if err := checkSourceIDs(id); err != nil {
+			t.Errorf("checkSourceIDs(%q) error = %v", id, err)
+		}
+	}
+}
+
+func TestSourceOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{
+			name: "notebook order",
+			raw:  `["Reading list",[[["src-c"],"Gamma"],[["src-a"],"Alpha"],[["src-b"],"Beta"]],"proj-1","📚"]`,
+			want: []string{"src-c", "src-a", "src-b"},
+		},
+		{
+			name: "no sources",
+			raw:  `["Empty",[],"proj-2","📚"]`,
+			want: []string{},
+		},
+		{
+			name: "sources slot missing",
+			raw:  `["Empty",null,"proj-3","📚"]`,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var project pb.Project
+			if err := beprotojson.Unmarshal([]byte(tt.raw), &project); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, sourceOrder(&project)); diff != "" {
+				t.Errorf("sourceOrder() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
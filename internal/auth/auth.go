@@ -32,12 +32,17 @@ func New(debug bool) *BrowserAuth {
 
 type Options struct {
 	ProfileName string
+	UserIndex   int
 }
 
 type Option func(*Options)
 
 func WithProfileName(p string) Option { return func(o *Options) { o.ProfileName = p } }
 
+// WithUserIndex extracts the auth token of the n-th signed-in Google account
+// in the profile instead of the first.
+func WithUserIndex(n int) Option { return func(o *Options) { o.UserIndex = n } }
+
 func (ba *BrowserAuth) GetAuth(opts ...Option) (token, cookies string, err error) {
 	o := &Options{
 		ProfileName: "Default",
@@ -115,7 +120,7 @@ func (ba *BrowserAuth) GetAuth(opts ...Option) (token, cookies string, err error
 		}))
 	}
 
-	return ba.extractAuthData(ctx)
+	return ba.extractAuthData(ctx, notebookLMURL(o.UserIndex))
 }
 
 func (ba *BrowserAuth) copyProfileData(profileName string) error {
@@ -256,10 +261,19 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-func (ba *BrowserAuth) extractAuthData(ctx context.Context) (token, cookies string, err error) {
+// notebookLMURL returns the NotebookLM home page for the given signed-in
+// account. Account 0 is the default.
+func notebookLMURL(userIndex int) string {
+	if userIndex > 0 {
+		return fmt.Sprintf("https://notebooklm.google.com/u/%d/", userIndex)
+	}
+	return "https://notebooklm.google.com"
+}
+
+func (ba *BrowserAuth) extractAuthData(ctx context.Context, pageURL string) (token, cookies string, err error) {
 	// Navigate and wait for initial page load
 	if err := chromedp.Run(ctx,
-		chromedp.Navigate("https://notebooklm.google.com"),
+		chromedp.Navigate(pageURL),
 		chromedp.WaitVisible("body", chromedp.ByQuery),
 	); err != nil {
 		return "", "", fmt.Errorf("failed to load page: %w", err)
@@ -0,0 +1,98 @@
+// Package backoff computes exponentially growing delays for polling and
+// retry loops.
+//
+// A typical retry loop looks like:
+//
+//	b := &backoff.Backoff{Initial: time.Second, Max: 30 * time.Second, MaxAttempts: 5}
+//	for {
+//		err := try()
+//		if err == nil {
+//			break
+//		}
+//		d := b.Next()
+//		if d == backoff.Stop {
+//			return err
+//		}
+//		time.Sleep(d)
+//	}
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Stop is returned by Next once MaxAttempts delays have been handed out.
+const Stop time.Duration = -1
+
+// DefaultMultiplier is used when Multiplier is less than one.
+const DefaultMultiplier = 2.0
+
+// Backoff hands out delays that start at Initial and grow by Multiplier
+// after each call to Next, up to Max. The zero value of each field means no
+// limit, except Multiplier, which defaults to DefaultMultiplier.
+//
+// A Backoff is not safe for concurrent use.
+type Backoff struct {
+	// Initial is the first delay returned by Next.
+	Initial time.Duration
+	// Max caps each delay before jitter is applied.
+	Max time.Duration
+	// Multiplier scales the delay after each call to Next. One keeps the
+	// delay constant.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in either
+	// direction, e.g. 0.2 yields delays within ±20%. It is clamped to [0, 1].
+	Jitter float64
+	// MaxAttempts is the number of delays Next returns before it returns
+	// Stop.
+	MaxAttempts int
+
+	attempts int
+	current  time.Duration
+}
+
+// Next returns the delay to wait before the next attempt, or Stop once
+// MaxAttempts delays have been returned.
+func (b *Backoff) Next() time.Duration {
+	if b.MaxAttempts > 0 && b.attempts >= b.MaxAttempts {
+		return Stop
+	}
+	if b.attempts == 0 {
+		b.current = b.Initial
+	} else {
+		m := b.Multiplier
+		if m < 1 {
+			m = DefaultMultiplier
+		}
+		b.current = time.Duration(float64(b.current) * m)
+	}
+	if b.Max > 0 && (b.current > b.Max || b.current < 0) {
+		b.current = b.Max
+	}
+	b.attempts++
+	return b.jitter(b.current)
+}
+
+// Attempts reports how many delays Next has returned since the last Reset.
+func (b *Backoff) Attempts() int {
+	return b.attempts
+}
+
+// Reset starts the sequence over from Initial.
+func (b *Backoff) Reset() {
+	b.attempts = 0
+	b.current = 0
+}
+
+func (b *Backoff) jitter(d time.Duration) time.Duration {
+	j := b.Jitter
+	if j <= 0 || d <= 0 {
+		return d
+	}
+	if j > 1 {
+		j = 1
+	}
+	delta := j * float64(d)
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
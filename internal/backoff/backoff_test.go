@@ -0,0 +1,72 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		b    Backoff
+		n    int
+		want []time.Duration
+	}{
+		{
+			name: "default multiplier",
+			b:    Backoff{Initial: time.Second},
+			n:    4,
+			want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name: "capped at max",
+			b:    Backoff{Initial: time.Second, Max: 3 * time.Second, Multiplier: 1.5},
+			n:    5,
+			want: []time.Duration{time.Second, 1500 * time.Millisecond, 2250 * time.Millisecond, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name: "max attempts",
+			b:    Backoff{Initial: time.Second, MaxAttempts: 2},
+			n:    4,
+			want: []time.Duration{time.Second, 2 * time.Second, Stop, Stop},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Duration
+			for i := 0; i < tt.n; i++ {
+				got = append(got, tt.b.Next())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Next() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := b.Next()
+		if d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("Next() = %v, want within [500ms, 1.5s]", d)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	b := Backoff{Initial: time.Second, MaxAttempts: 1}
+	b.Next()
+	if got := b.Next(); got != Stop {
+		t.Fatalf("Next() = %v, want Stop", got)
+	}
+	b.Reset()
+	if got := b.Attempts(); got != 0 {
+		t.Errorf("Attempts() after Reset = %d, want 0", got)
+	}
+	if got := b.Next(); got != time.Second {
+		t.Errorf("Next() after Reset = %v, want %v", got, time.Second)
+	}
+}
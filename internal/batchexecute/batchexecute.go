@@ -2,6 +2,11 @@ package batchexecute
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +15,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,12 +26,39 @@ import (
 // ErrUnauthorized represent an unauthorized request.
 var ErrUnauthorized = errors.New("unauthorized")
 
+// ErrUnauthenticated is returned when the server answers with Google's
+// sign-in or consent page instead of batchexecute data, which happens when
+// the session cookies have expired. It wraps ErrUnauthorized.
+var ErrUnauthenticated = fmt.Errorf("%w: session expired, please re-login", ErrUnauthorized)
+
+// ErrRateLimited represents a request rejected with HTTP 429 Too Many Requests.
+// The recommended wait, if the server sent one, is available in the
+// RetryAfter field of the wrapping *BatchExecuteError.
+var ErrRateLimited = errors.New("rate limited")
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// with WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrNoRecordedResponse is returned in replay mode when the replay
+// directory holds no response for an RPC.
+var ErrNoRecordedResponse = errors.New("no recorded response")
+
 // RPC represents a single RPC call
 type RPC struct {
 	ID        string            // RPC endpoint ID
+	Name      string            // Optional human-readable name, for metrics
 	Args      []interface{}     // Arguments for the call
 	Index     string            // "generic" or numeric index
 	URLParams map[string]string // Request-specific URL parameters
+	Headers   map[string]string // Request-specific headers; see protectedHeaders
+}
+
+// Observer receives the outcome of every RPC executed by a Client. It can be
+// used to export latency and error metrics without this package depending
+// on a metrics library.
+type Observer interface {
+	ObserveRPC(id, name string, duration time.Duration, err error)
 }
 
 // Response represents a decoded RPC response
@@ -36,6 +70,9 @@ type Response struct {
 	// RawArray preserves the entire response array for APIs that need access
 	// to non-standard response fields (e.g., CheckSourceFreshness uses position [5])
 	RawArray []interface{} `json:"-"`
+	// Fragments holds the data of every wrb.fr frame received for this RPC,
+	// in arrival order, when the server streamed more than one.
+	Fragments []json.RawMessage `json:"-"`
 }
 
 // BatchExecuteError represents a batchexecute error
@@ -43,22 +80,174 @@ type BatchExecuteError struct {
 	StatusCode int
 	Message    string
 	Response   *http.Response
+	// RetryAfter is the wait recommended by the server's Retry-After
+	// header, or zero if the header was absent or unparseable.
+	RetryAfter time.Duration
 }
 
 func (e *BatchExecuteError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("batchexecute error: %s (status: %d, retry after: %s)", e.Message, e.StatusCode, e.RetryAfter)
+	}
 	return fmt.Sprintf("batchexecute error: %s (status: %d)", e.Message, e.StatusCode)
 }
 
 func (e *BatchExecuteError) Unwrap() error {
-	if e.StatusCode == 401 {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
 		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
 	}
 	return nil
 }
 
+// RPCError is the error status the server attached to an RPC, either in the
+// status slot (index 5) of a wrb.fr frame that carries no data or in an "er"
+// frame. Code is the google.rpc status code, e.g. 3 for INVALID_ARGUMENT.
+type RPCError struct {
+	Code    int
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("rpc error: code %d", e.Code)
+	}
+	return fmt.Sprintf("rpc error: code %d: %s", e.Code, e.Message)
+}
+
+// Err returns the server's error for this response as an *RPCError, or nil
+// if the response carries data or no error status. Some RPCs, such as
+// CheckSourceFreshness, report results in the status slot alongside data;
+// callers of those should read RawArray instead.
+func (r *Response) Err() error {
+	if len(r.RawArray) == 0 {
+		return nil
+	}
+	if typ, _ := r.RawArray[0].(string); typ == "er" {
+		return erFrameError(r.RawArray)
+	}
+	if !IsEmptyData(r.Data) || len(r.RawArray) < 6 {
+		return nil
+	}
+	status, ok := r.RawArray[5].([]interface{})
+	if !ok || len(status) == 0 {
+		return nil
+	}
+	code, _ := status[0].(float64)
+	if code == 0 {
+		return nil
+	}
+	e := &RPCError{Code: int(code)}
+	if len(status) > 1 {
+		if msg, ok := status[1].(string); ok {
+			e.Message = msg
+		}
+	}
+	if e.Message == "" && len(status) > 2 {
+		// Details are google.protobuf.Any values such as
+		// ["type.googleapis.com/google.rpc.LocalizedMessage", ["en", "..."]];
+		// the last plain string is the human-readable text.
+		walkStrings(status[2:], func(s string) {
+			if !strings.HasPrefix(s, "type.googleapis.com/") {
+				e.Message = s
+			}
+		})
+	}
+	return e
+}
+
+// erFrameError builds an *RPCError from an "er" frame, which has no fixed
+// layout: the code is its first number and the message its first string
+// after the frame type and RPC ID.
+func erFrameError(frame []interface{}) *RPCError {
+	e := &RPCError{}
+	for _, v := range frame[1:] {
+		if n, ok := v.(float64); ok {
+			e.Code = int(n)
+			break
+		}
+	}
+	if len(frame) > 2 {
+		walkStrings(frame[2:], func(s string) {
+			if e.Message == "" {
+				e.Message = s
+			}
+		})
+	}
+	return e
+}
+
+// walkStrings calls fn for every string in v, depth first.
+func walkStrings(v interface{}, fn func(string)) {
+	switch v := v.(type) {
+	case string:
+		fn(v)
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	}
+}
+
+// loginMarkers are substrings of Google's sign-in and consent pages.
+var loginMarkers = []string{
+	"accounts.google.com/ServiceLogin",
+	"accounts.google.com/v3/signin",
+	"consent.google.com",
+}
+
+// IsLoginPage reports whether body is an HTML page, or mentions a Google
+// sign-in URL, rather than a batchexecute payload.
+func IsLoginPage(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	prefix := strings.ToLower(string(trimmed[:min(len(trimmed), len("<!doctype"))]))
+	if strings.HasPrefix(prefix, "<!doctype") || strings.HasPrefix(prefix, "<html") {
+		return true
+	}
+	if bytes.HasPrefix(trimmed, []byte(")]}'")) {
+		return false
+	}
+	for _, marker := range loginMarkers {
+		if bytes.Contains(body, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a
+// number of seconds or an HTTP-date. It returns zero if the value is
+// empty, malformed, or in the past.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // Do executes a single RPC call
 func (c *Client) Do(rpc RPC) (*Response, error) {
-	return c.Execute([]RPC{rpc})
+	if c.observer == nil {
+		return c.Execute([]RPC{rpc})
+	}
+	start := time.Now()
+	resp, err := c.Execute([]RPC{rpc})
+	c.observer.ObserveRPC(rpc.ID, rpc.Name, time.Since(start), err)
+	return resp, err
 }
 
 func buildRPCData(rpc RPC) []interface{} {
@@ -73,15 +262,30 @@ func buildRPCData(rpc RPC) []interface{} {
 	}
 }
 
-// Execute performs the batch execute request
+// Execute performs the batch execute request. If the session has expired
+// and WithAutoReauth is set, it fetches fresh credentials and retries once.
 func (c *Client) Execute(rpcs []RPC) (*Response, error) {
-	u, err := url.Parse(fmt.Sprintf("https://%s/_/%s/data/batchexecute", c.config.Host, c.config.App))
+	resp, err := c.execute(rpcs)
+	if c.reauth == nil || !errors.Is(err, ErrUnauthenticated) {
+		return resp, err
+	}
+	authToken, cookies, reauthErr := c.reauth()
+	if reauthErr != nil {
+		return nil, fmt.Errorf("reauthenticate: %v: %w", reauthErr, err)
+	}
+	c.credMu.Lock()
+	c.config.AuthToken = authToken
+	c.config.Cookies = cookies
+	c.credMu.Unlock()
+	return c.execute(rpcs)
+}
+
+func (c *Client) execute(rpcs []RPC) (*Response, error) {
+	authToken, _ := c.credentials()
+	u, err := url.Parse(fmt.Sprintf("%s%s/_/%s/data/batchexecute", c.BaseURL(), c.userPath, c.config.App))
 	if err != nil {
 		return nil, fmt.Errorf("parse url: %w", err)
 	}
-	if c.config.UseHTTP {
-		u.Scheme = "http"
-	}
 
 	// Add query parameters
 	q := u.Query()
@@ -119,17 +323,172 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 
 	form := url.Values{}
 	form.Set("f.req", string(reqBody))
-	form.Set("at", c.config.AuthToken)
+	form.Set("at", authToken)
 
 	if c.config.Debug {
 		fmt.Printf("\nRequest Body:\n%s\n", form.Encode())
 		fmt.Printf("\nDecoded Request Body:\n%s\n", string(reqBody))
 	}
 
-	// Create request
-	req, err := http.NewRequest("POST", u.String(), strings.NewReader(form.Encode()))
+	var (
+		resp *http.Response
+		body []byte
+	)
+	for attempt := 0; ; attempt++ {
+		resp, body, err = c.send(u.String(), form.Encode(), rpcs[0].Headers, c.timeouts[rpcs[0].ID])
+		if err != nil {
+			return nil, err
+		}
+		if c.config.Debug {
+			c.saveDebugArtifact(rpcs[0].ID, body)
+		}
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+
+		beErr := &BatchExecuteError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("request failed: %s", resp.Status),
+			Response:   resp,
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			beErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= c.rateLimitRetries {
+			return nil, beErr
+		}
+
+		wait := beErr.RetryAfter
+		if wait == 0 {
+			wait = defaultRateLimitWait
+		}
+		if c.config.Debug {
+			fmt.Printf("Rate limited, retrying in %s (attempt %d/%d)\n", wait, attempt+1, c.rateLimitRetries)
+		}
+		sleep(wait)
+	}
+
+	if IsLoginPage(body) {
+		return nil, ErrUnauthenticated
+	}
+	// Parse chunked response
+	responses, err := decodeChunkedResponse(string(body))
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		if c.config.Debug {
+			fmt.Printf("Failed to decode chunked response: %v\n", err)
+		}
+		// Fallback to regular response parsing
+		responses, err = decodeResponse(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no valid responses found")
+	}
+	if c.recordDir != "" {
+		c.record(rpcs[0].ID, reqBody, body)
+	}
+
+	responses = mergeResponses(responses)
+	for i := range responses {
+		if responses[i].ID == rpcs[0].ID {
+			return &responses[i], nil
+		}
+	}
+	return &responses[0], nil
+}
+
+// mergeResponses combines wrb.fr frames that belong to the same RPC. Long
+// responses may be streamed as several frames, each a more complete snapshot
+// of the result, so the merged Data is the last non-empty frame while every
+// frame is kept in Fragments. Order of first appearance is preserved.
+func mergeResponses(responses []Response) []Response {
+	type key struct {
+		id    string
+		index int
+	}
+	var merged []Response
+	pos := make(map[key]int)
+	for _, r := range responses {
+		k := key{r.ID, r.Index}
+		i, ok := pos[k]
+		if !ok {
+			pos[k] = len(merged)
+			merged = append(merged, r)
+			continue
+		}
+		m := &merged[i]
+		if len(m.Fragments) == 0 {
+			m.Fragments = []json.RawMessage{m.Data}
+		}
+		m.Fragments = append(m.Fragments, r.Data)
+		if !IsEmptyData(r.Data) || r.Err() != nil {
+			m.Data = r.Data
+			m.RawArray = r.RawArray
+		}
+		if r.Error != "" {
+			m.Error = r.Error
+		}
+	}
+	return merged
+}
+
+// IsEmptyData reports whether d carries no result: it is blank, null, or
+// the empty array that the chunked decoder substitutes for a null data slot.
+func IsEmptyData(d json.RawMessage) bool {
+	s := strings.TrimSpace(string(d))
+	return s == "" || s == "null" || s == "[]"
+}
+
+// saveDebugArtifact writes a raw response body to the debug directory so it
+// can be inspected or replayed later. Failures are reported but not fatal.
+func (c *Client) saveDebugArtifact(rpcID string, body []byte) {
+	dir := c.debugDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	name := fmt.Sprintf("nlm-%s-%s.txt", rpcID, time.Now().Format("20060102T150405.000000000"))
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "debug: save response: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, body, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "debug: save response: %v\n", err)
+		return
+	}
+	fmt.Printf("Saved raw response to %s\n", path)
+}
+
+// protectedHeaders are required by the batchexecute protocol and cannot be
+// overridden by RPC.Headers.
+var protectedHeaders = map[string]bool{
+	"Content-Type":  true,
+	"Cookie":        true,
+	"X-Same-Domain": true,
+}
+
+// send issues a single POST of the encoded form to rawURL and returns the
+// response along with its fully read body. extra holds per-request headers,
+// applied after the client's own. A positive timeout bounds the whole
+// exchange, including reading the body, in place of the HTTP client's own.
+func (c *Client) send(rawURL, form string, extra map[string]string, timeout time.Duration) (*http.Response, []byte, error) {
+	ctx := context.Background()
+	httpClient := c.httpClient
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		hc := *c.httpClient
+		hc.Timeout = 0
+		httpClient = &hc
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", rawURL, strings.NewReader(form))
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// Set headers
@@ -137,7 +496,14 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 	for k, v := range c.config.Headers {
 		req.Header.Set(k, v)
 	}
-	req.Header.Set("cookie", c.config.Cookies)
+	for k, v := range extra {
+		if protectedHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	_, cookies := c.credentials()
+	req.Header.Set("cookie", cookies)
 
 	if c.config.Debug {
 		fmt.Printf("\nRequest Headers:\n")
@@ -147,48 +513,45 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 	}
 
 	// Execute request
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+		return nil, nil, fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
-
-	if c.config.Debug {
-		fmt.Printf("\nResponse Status: %s\n", resp.Status)
-		fmt.Printf("Response Body:\n%s\n", string(body))
+	// Go's transport asks for gzip and decompresses transparently, unless
+	// the request set its own Accept-Encoding (e.g. through WithHeaders) or
+	// the transport has compression disabled. Decode those bodies here.
+	var r io.Reader = resp.Body
+	if !resp.Uncompressed && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read gzip response: %w", err)
+		}
+		defer zr.Close()
+		r = zr
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, &BatchExecuteError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("request failed: %s", resp.Status),
-			Response:   resp,
+	var body []byte
+	if c.maxResponseSize > 0 {
+		// Read one byte past the limit so an oversized body is detected
+		// without buffering all of it.
+		body, err = io.ReadAll(io.LimitReader(r, c.maxResponseSize+1))
+		if err == nil && int64(len(body)) > c.maxResponseSize {
+			return nil, nil, fmt.Errorf("%w (limit is %d bytes)", ErrResponseTooLarge, c.maxResponseSize)
 		}
+	} else {
+		body, err = io.ReadAll(r)
 	}
-
-	// Parse chunked response
-	responses, err := decodeChunkedResponse(string(body))
 	if err != nil {
-		if c.config.Debug {
-			fmt.Printf("Failed to decode chunked response: %v\n", err)
-		}
-		// Fallback to regular response parsing
-		responses, err = decodeResponse(string(body))
-		if err != nil {
-			return nil, fmt.Errorf("decode response: %w", err)
-		}
+		return nil, nil, fmt.Errorf("read response: %w", err)
 	}
 
-	if len(responses) == 0 {
-		return nil, fmt.Errorf("no valid responses found")
+	if c.config.Debug {
+		fmt.Printf("\nResponse Status: %s\n", resp.Status)
+		fmt.Printf("Response Body:\n%s\n", string(body))
 	}
-
-	return &responses[0], nil
+	return resp, body, nil
 }
 
 var debug = false
@@ -218,10 +581,18 @@ func decodeResponse(raw string) ([]Response, error) {
 
 	var result []Response
 	for _, rpcData := range responseArray {
-		if len(rpcData) < 7 {
+		if len(rpcData) == 0 {
 			continue
 		}
 		rpcType, ok := rpcData[0].(string)
+		if ok && rpcType == "er" && len(rpcData) > 1 {
+			id, _ := rpcData[1].(string)
+			result = append(result, Response{ID: id, RawArray: rpcData})
+			continue
+		}
+		if len(rpcData) < 7 {
+			continue
+		}
 		if !ok || rpcType != "wrb.fr" {
 			continue
 		}
@@ -357,13 +728,21 @@ func decodeChunkedResponse(raw string) ([]Response, error) {
 
 		// Process each RPC response in the batch
 		for _, rpcData := range rpcBatch {
+			if len(rpcData) == 0 {
+				continue
+			}
+			rpcType, ok := rpcData[0].(string)
+			if ok && rpcType == "er" && len(rpcData) > 1 {
+				id, _ := rpcData[1].(string)
+				responses = append(responses, Response{ID: id, RawArray: rpcData})
+				continue
+			}
 			if len(rpcData) < 7 {
 				if debug {
 					fmt.Printf("Skipping short RPC data: %v\n", rpcData)
 				}
 				continue
 			}
-			rpcType, ok := rpcData[0].(string)
 			if !ok || rpcType != "wrb.fr" {
 				if debug {
 					fmt.Printf("Skipping non-wrb.fr RPC: %v\n", rpcData[0])
@@ -500,6 +879,68 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithTransport sets the transport used for requests, e.g. an *http.Transport
+// tuned to keep more idle connections open during bulk operations. The
+// default is http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		if c.httpClient == http.DefaultClient {
+			c.httpClient = &http.Client{
+				Transport: rt,
+			}
+		} else {
+			c.httpClient.Transport = rt
+		}
+	}
+}
+
+// WithMaxIdleConnsPerHost keeps up to n idle connections to the server open
+// for reuse, instead of the default of two, so that many concurrent calls
+// do not each pay for a new TLS handshake. It installs a copy of
+// http.DefaultTransport; use WithTransport for finer control.
+func WithMaxIdleConnsPerHost(n int) Option {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = n
+	if t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+		t.MaxIdleConns = n
+	}
+	return WithTransport(t)
+}
+
+// WithTimeoutFor sets the timeout for calls to the RPC with the given ID,
+// overriding WithTimeout for that RPC only. Use it to give slow operations
+// such as audio generation minutes while keeping other calls short:
+//
+//	WithTimeoutFor(rpc.RPCCreateAudioOverview, 5*time.Minute)
+func WithTimeoutFor(rpcID string, timeout time.Duration) Option {
+	return func(c *Client) {
+		if c.timeouts == nil {
+			c.timeouts = make(map[string]time.Duration)
+		}
+		c.timeouts[rpcID] = timeout
+	}
+}
+
+// WithAutoReauth sets a callback that supplies fresh credentials when a call
+// fails with ErrUnauthenticated. The call is retried once with the new auth
+// token and cookies, which are kept for all later calls. A second failure is
+// returned as is.
+func WithAutoReauth(fn func() (authToken, cookies string, err error)) Option {
+	return func(c *Client) {
+		c.reauth = fn
+	}
+}
+
+// WithMaxResponseSize limits response bodies to n bytes. Larger responses
+// are discarded and the call fails with ErrResponseTooLarge. Audio overviews
+// arrive inline as base64, so leave room for them if they are fetched. The
+// default of zero means no limit.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = n
+	}
+}
+
 // WithHeaders adds additional headers
 func WithHeaders(headers map[string]string) Option {
 	return func(c *Client) {
@@ -524,6 +965,185 @@ func WithURLParams(params map[string]string) Option {
 	}
 }
 
+// defaultRateLimitWait is used between rate-limit retries when the server
+// does not send a Retry-After header.
+const defaultRateLimitWait = 5 * time.Second
+
+// sleep is replaced in tests to avoid real delays between retries.
+var sleep = time.Sleep
+
+// WithRateLimitRetries retries requests rejected with HTTP 429 up to n
+// times, sleeping for the server's Retry-After interval between attempts.
+// With the default of zero, a 429 is returned immediately as an error
+// wrapping ErrRateLimited.
+func WithRateLimitRetries(n int) Option {
+	return func(c *Client) {
+		c.rateLimitRetries = n
+	}
+}
+
+// WithMetrics reports the duration and error of every RPC to observer.
+func WithMetrics(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// WithBaseURL points the client at baseURL (e.g. "http://127.0.0.1:8080")
+// instead of the configured host, for mock servers or alternate endpoints.
+// An http scheme disables TLS. A value without a scheme is taken as a host.
+// Origin and referer headers, if set, are rewritten to match.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Host == "" {
+			c.config.Host = strings.TrimSuffix(baseURL, "/")
+			return
+		}
+		c.config.Host = u.Host
+		c.config.UseHTTP = u.Scheme == "http"
+
+		headers := make(map[string]string, len(c.config.Headers))
+		for k, v := range c.config.Headers {
+			headers[k] = v
+		}
+		if _, ok := headers["origin"]; ok {
+			headers["origin"] = c.BaseURL()
+		}
+		if _, ok := headers["referer"]; ok {
+			headers["referer"] = c.BaseURL() + "/"
+		}
+		c.config.Headers = headers
+	}
+}
+
+// WithDebugDir sets where raw responses are saved when debug output is
+// enabled. Files are named after the RPC ID and a timestamp. The default is
+// os.TempDir().
+func WithDebugDir(dir string) Option {
+	return func(c *Client) {
+		c.debugDir = dir
+	}
+}
+
+// WithRecordDir saves every successful exchange to dir, for later use with
+// WithReplayDir in tests or bug reports. The response body is written to
+// <rpcID>-<hash>.txt and the request payload, whose hash names the files,
+// to <rpcID>-<hash>.req.json. The payload holds the RPC arguments but not
+// the auth token or cookies, so re-recording the same calls overwrites the
+// same files. Failures to write are reported but not fatal.
+func WithRecordDir(dir string) Option {
+	return func(c *Client) {
+		c.recordDir = dir
+	}
+}
+
+// exchangeName returns the base name under which an exchange is recorded:
+// the RPC ID and a hash of the f.req payload carrying its arguments.
+func exchangeName(rpcID string, freq []byte) string {
+	sum := sha256.Sum256(freq)
+	return rpcID + "-" + hex.EncodeToString(sum[:8])
+}
+
+// record writes an exchange to the record directory.
+func (c *Client) record(rpcID string, freq, body []byte) {
+	name := filepath.Join(c.recordDir, exchangeName(rpcID, freq))
+	if err := os.MkdirAll(c.recordDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(name+".req.json", freq, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(name+".txt", body, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+	}
+}
+
+// WithReplayDir answers requests from responses recorded in dir instead of
+// the network, so that a parsing problem can be reproduced from a bug
+// report without credentials. For RPC ID X, the response is read from the
+// first of these files that exists in dir:
+//
+//   - the exchange recorded by WithRecordDir for the same arguments
+//   - X.txt, answering every call to X
+//   - the most recent nlm-X-*.txt saved by debug mode (see WithDebugDir)
+//
+// A request for an RPC with no recorded response fails with
+// ErrNoRecordedResponse. The replay transport takes the place of the HTTP
+// client, so this option should not be combined with WithHTTPClient or
+// WithTransport.
+func WithReplayDir(dir string) Option {
+	return func(c *Client) {
+		c.httpClient = &http.Client{Transport: replayTransport(dir)}
+	}
+}
+
+// replayTransport serves recorded response bodies from a directory, keyed
+// by the rpcids query parameter and the f.req form value.
+type replayTransport string
+
+func (dir replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var freq []byte
+	if req.Body != nil {
+		form, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("replay: read request: %w", err)
+		}
+		values, err := url.ParseQuery(string(form))
+		if err != nil {
+			return nil, fmt.Errorf("replay: parse request: %w", err)
+		}
+		freq = []byte(values.Get("f.req"))
+	}
+	rpcID := req.URL.Query().Get("rpcids")
+	path, err := dir.find(rpcID, freq)
+	if err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay %s: %w", rpcID, err)
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Header:        http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// find returns the file holding the recorded response for rpcID called with
+// freq. Debug artifacts carry a sortable timestamp, so the last match is the
+// newest.
+func (dir replayTransport) find(rpcID string, freq []byte) (string, error) {
+	for _, name := range []string{exchangeName(rpcID, freq) + ".txt", rpcID + ".txt"} {
+		path := filepath.Join(string(dir), name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	matches, err := filepath.Glob(filepath.Join(string(dir), "nlm-"+rpcID+"-*.txt"))
+	if err != nil || len(matches) == 0 {
+		return "", fmt.Errorf("replay %s from %s: %w", rpcID, string(dir), ErrNoRecordedResponse)
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// WithUserIndex targets the n-th signed-in Google account, as the /u/N/
+// path segment does in the browser, for users logged into several accounts.
+// Without it requests go to the default (first) account.
+func WithUserIndex(n int) Option {
+	return func(c *Client) {
+		c.userPath = fmt.Sprintf("/u/%d", n)
+	}
+}
+
 // WithReqIDGenerator sets the request ID generator
 func WithReqIDGenerator(reqid *ReqIDGenerator) Option {
 	return func(c *Client) {
@@ -531,6 +1151,16 @@ func WithReqIDGenerator(reqid *ReqIDGenerator) Option {
 	}
 }
 
+// WithReqIDSeed starts the _reqid sequence at seed instead of a random
+// 4-digit value. Each subsequent request adds 100000, as the web client does.
+// Long-running services can persist ReqIDGenerator.Seed across restarts and
+// pass it back here so request IDs keep increasing rather than colliding.
+func WithReqIDSeed(seed int) Option {
+	return func(c *Client) {
+		c.reqid = NewSeededReqIDGenerator(seed)
+	}
+}
+
 // Config holds the configuration for batch execute
 type Config struct {
 	Host      string
@@ -549,6 +1179,17 @@ type Client struct {
 	httpClient *http.Client
 	debug      func(format string, args ...interface{})
 	reqid      *ReqIDGenerator
+
+	rateLimitRetries int
+	observer         Observer
+	debugDir         string
+	recordDir        string
+	timeouts         map[string]time.Duration
+	maxResponseSize  int64
+	userPath         string // "/u/N" account selector, if any
+
+	reauth func() (authToken, cookies string, err error)
+	credMu sync.RWMutex // guards config.AuthToken and config.Cookies
 }
 
 // GetDebug returns the debug flag setting
@@ -571,9 +1212,78 @@ func NewClient(config Config, opts ...Option) *Client {
 }
 
 func (c *Client) Config() Config {
+	c.credMu.RLock()
+	defer c.credMu.RUnlock()
 	return c.config
 }
 
+// credentials returns the current auth token and cookies, which
+// WithAutoReauth may replace between calls.
+func (c *Client) credentials() (authToken, cookies string) {
+	c.credMu.RLock()
+	defer c.credMu.RUnlock()
+	return c.config.AuthToken, c.config.Cookies
+}
+
+// HTTPClient returns the HTTP client requests are sent with, as set by
+// WithHTTPClient, WithTransport or WithReplayDir.
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// BaseURL returns the scheme and host requests are sent to.
+func (c *Client) BaseURL() string {
+	if c.config.UseHTTP {
+		return "http://" + c.config.Host
+	}
+	return "https://" + c.config.Host
+}
+
+// Close releases idle connections held by the client's HTTP client. The
+// shared http.DefaultClient is left untouched.
+func (c *Client) Close() error {
+	if c.httpClient != http.DefaultClient {
+		c.httpClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// WithCredentials returns a client for another account that shares c's
+// HTTP client, and so its connection pool, along with its other settings.
+// The clone keeps its own credentials and request IDs. A WithAutoReauth
+// callback is not carried over, since it would fetch c's credentials.
+func (c *Client) WithCredentials(authToken, cookies string) *Client {
+	config := c.Config()
+	config.AuthToken = authToken
+	config.Cookies = cookies
+	config.Headers = cloneStringMap(config.Headers)
+	config.URLParams = cloneStringMap(config.URLParams)
+	return &Client{
+		config:           config,
+		httpClient:       c.httpClient,
+		debug:            c.debug,
+		reqid:            NewReqIDGenerator(),
+		rateLimitRetries: c.rateLimitRetries,
+		observer:         c.observer,
+		debugDir:         c.debugDir,
+		recordDir:        c.recordDir,
+		timeouts:         c.timeouts,
+		maxResponseSize:  c.maxResponseSize,
+		userPath:         c.userPath,
+	}
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
 // ReqIDGenerator generates sequential request IDs
 type ReqIDGenerator struct {
 	base     int // Initial 4-digit number
@@ -594,6 +1304,13 @@ func NewReqIDGenerator() *ReqIDGenerator {
 	}
 }
 
+// NewSeededReqIDGenerator creates a request ID generator whose first ID is seed.
+func NewSeededReqIDGenerator(seed int) *ReqIDGenerator {
+	return &ReqIDGenerator{
+		base: seed,
+	}
+}
+
 // Next returns the next request ID in sequence
 func (g *ReqIDGenerator) Next() string {
 	g.mu.Lock()
@@ -603,6 +1320,14 @@ func (g *ReqIDGenerator) Next() string {
 	return strconv.Itoa(reqid)
 }
 
+// Seed returns the ID that Next would return, suitable for persisting and
+// passing to WithReqIDSeed to resume the sequence in a new process.
+func (g *ReqIDGenerator) Seed() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.base + (g.sequence * 100000)
+}
+
 // Reset resets the sequence counter but keeps the same base
 func (g *ReqIDGenerator) Reset() {
 	g.mu.Lock()
@@ -1,13 +1,19 @@
 package batchexecute
 
 import (
+	"compress/gzip"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -236,3 +242,727 @@ func TestExecute(t *testing.T) {
 		t.Errorf("Unexpected response data:\ngot:  %s\nwant: %s", string(response.Data), string(expectedData))
 	}
 }
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Empty", value: "", want: 0},
+		{name: "Seconds", value: "120", want: 2 * time.Minute},
+		{name: "Negative Seconds", value: "-5", want: 0},
+		{name: "HTTP Date", value: "Wed, 20 Nov 2024 12:00:30 GMT", want: 30 * time.Second},
+		{name: "HTTP Date In Past", value: "Wed, 20 Nov 2024 11:59:00 GMT", want: 0},
+		{name: "Garbage", value: "soon", want: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseRetryAfter(tc.value, now); got != tc.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecuteRateLimited(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()), WithRateLimitRetries(1))
+
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	_, err := client.Do(RPC{ID: "wXbhsf"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	var beErr *BatchExecuteError
+	if !errors.As(err, &beErr) {
+		t.Fatalf("expected *BatchExecuteError, got %T", err)
+	}
+	if beErr.RetryAfter != 7*time.Second {
+		t.Errorf("RetryAfter = %v, want 7s", beErr.RetryAfter)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests (1 retry), got %d", requests)
+	}
+	if len(slept) != 1 || slept[0] != defaultRateLimitWait {
+		t.Errorf("expected one default wait between attempts, got %v", slept)
+	}
+}
+
+type recordingObserver struct {
+	ids, names []string
+	errs       []error
+}
+
+func (o *recordingObserver) ObserveRPC(id, name string, duration time.Duration, err error) {
+	o.ids = append(o.ids, id)
+	o.names = append(o.names, name)
+	o.errs = append(o.errs, err)
+}
+
+func TestWithMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+	observer := &recordingObserver{}
+	client := NewClient(config, WithHTTPClient(server.Client()), WithMetrics(observer))
+
+	if _, err := client.Do(RPC{ID: "rLM1Ne", Name: "GetProject"}); err == nil {
+		t.Fatal("expected error from failing server")
+	}
+	if len(observer.ids) != 1 {
+		t.Fatalf("expected 1 observation, got %d", len(observer.ids))
+	}
+	if observer.ids[0] != "rLM1Ne" || observer.names[0] != "GetProject" {
+		t.Errorf("observed %s/%s, want rLM1Ne/GetProject", observer.ids[0], observer.names[0])
+	}
+	if observer.errs[0] == nil {
+		t.Error("expected observed error")
+	}
+}
+
+func TestExecuteStreamedResponse(t *testing.T) {
+	content, err := testdata.ReadFile("testdata/streamed_project.txt")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()))
+
+	response, err := client.Do(RPC{ID: "rLM1Ne", Index: "generic"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if response.ID != "rLM1Ne" {
+		t.Errorf("ID = %q, want rLM1Ne", response.ID)
+	}
+	if len(response.Fragments) != 2 {
+		t.Fatalf("expected 2 fragments, got %d", len(response.Fragments))
+	}
+	expectedData := `[["Streamed notebook",[[["c1a2b3"],"First source"]],"0ab9f2d6-6d1e-4d2b-9c88-3f4f2b9f0a11","📚"]]`
+	if string(response.Data) != expectedData {
+		t.Errorf("Unexpected response data:\ngot:  %s\nwant: %s", response.Data, expectedData)
+	}
+}
+
+func TestWithReqIDSeed(t *testing.T) {
+	var reqids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqids = append(reqids, r.URL.Query().Get("_reqid"))
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()), WithReqIDSeed(4242))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if diff := cmp.Diff([]string{"4242", "104242"}, reqids); diff != "" {
+		t.Errorf("_reqid mismatch (-want +got):\n%s", diff)
+	}
+	if got := client.reqid.Seed(); got != 204242 {
+		t.Errorf("Seed() = %d, want 204242", got)
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	var origin string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin = r.Header.Get("origin")
+		if r.URL.Path != "/_/notebooklm/data/batchexecute" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    "notebooklm.google.com",
+		App:     "notebooklm",
+		Headers: map[string]string{"origin": "https://notebooklm.google.com"},
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()), WithBaseURL(server.URL))
+	if got := client.BaseURL(); got != server.URL {
+		t.Errorf("BaseURL() = %q, want %q", got, server.URL)
+	}
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if origin != server.URL {
+		t.Errorf("origin header = %q, want %q", origin, server.URL)
+	}
+	if config.Headers["origin"] != "https://notebooklm.google.com" {
+		t.Error("WithBaseURL modified the caller's headers")
+	}
+}
+
+func TestExecuteLoginPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Sign in - Google Accounts</title></head>
+<body><form action="https://accounts.google.com/v3/signin/identifier"></form></body></html>`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()))
+
+	_, err := client.Do(RPC{ID: "wXbhsf"})
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected error to wrap ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestWithAutoReauth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != "SID=fresh" || r.FormValue("at") != "fresh-token" {
+			fmt.Fprint(w, `<!DOCTYPE html><html><body>Sign in</body></html>`)
+			return
+		}
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:      strings.TrimPrefix(server.URL, "http://"),
+		App:       "notebooklm",
+		UseHTTP:   true,
+		AuthToken: "stale-token",
+		Cookies:   "SID=stale",
+	}
+
+	t.Run("retries once with fresh credentials", func(t *testing.T) {
+		calls := 0
+		client := NewClient(config, WithHTTPClient(server.Client()), WithAutoReauth(func() (string, string, error) {
+			calls++
+			return "fresh-token", "SID=fresh", nil
+		}))
+		for i := 0; i < 2; i++ {
+			if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("reauth called %d times, want 1", calls)
+		}
+	})
+
+	t.Run("gives up after one retry", func(t *testing.T) {
+		calls := 0
+		client := NewClient(config, WithHTTPClient(server.Client()), WithAutoReauth(func() (string, string, error) {
+			calls++
+			return "still-stale", "SID=stale", nil
+		}))
+		_, err := client.Do(RPC{ID: "wXbhsf"})
+		if !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("Do() error = %v, want ErrUnauthenticated", err)
+		}
+		if calls != 1 {
+			t.Errorf("reauth called %d times, want 1", calls)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		client := NewClient(config, WithHTTPClient(server.Client()), WithAutoReauth(func() (string, string, error) {
+			return "", "", errors.New("no browser profile")
+		}))
+		_, err := client.Do(RPC{ID: "wXbhsf"})
+		if !errors.Is(err, ErrUnauthenticated) || !strings.Contains(err.Error(), "no browser profile") {
+			t.Errorf("Do() error = %v", err)
+		}
+	})
+}
+
+func TestIsLoginPage(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "Doctype", body: "\n<!doctype html><html></html>", want: true},
+		{name: "HTML", body: "<HTML><body>hi</body></HTML>", want: true},
+		{name: "Sign-in Redirect", body: `Moved to https://accounts.google.com/ServiceLogin?continue=x`, want: true},
+		{name: "Batchexecute", body: ")]}'\n\n[[\"wrb.fr\",\"x\",\"[\\\"https://accounts.google.com/ServiceLogin\\\"]\"]]", want: false},
+		{name: "Empty", body: "", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsLoginPage([]byte(tc.body)); got != tc.want {
+				t.Errorf("IsLoginPage(%q) = %v, want %v", tc.body, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRPCHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+		Cookies: "SID=abc",
+		Headers: map[string]string{"x-same-domain": "1", "accept-language": "en"},
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()))
+
+	_, err := client.Do(RPC{
+		ID: "wXbhsf",
+		Headers: map[string]string{
+			"X-Goog-Experiment": "on",
+			"accept-language":   "fr",
+			"content-type":      "application/json",
+			"cookie":            "SID=evil",
+			"X-Same-Domain":     "0",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"X-Goog-Experiment": "on",
+		"Accept-Language":   "fr",
+		"Content-Type":      "application/x-www-form-urlencoded;charset=UTF-8",
+		"Cookie":            "SID=abc",
+		"X-Same-Domain":     "1",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("header %s = %q, want %q", k, got.Get(k), v)
+		}
+	}
+}
+
+func TestWithDebugDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+		Debug:   true,
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()), WithDebugDir(dir))
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "nlm-wXbhsf-*.txt"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected one saved response, got %v (%v)", matches, err)
+	}
+	saved, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), `"wrb.fr","wXbhsf"`) {
+		t.Errorf("saved response = %q", saved)
+	}
+}
+
+func TestWithTimeoutFor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		id := r.URL.Query().Get("rpcids")
+		fmt.Fprintf(w, ")]}'\n\n[[\"wrb.fr\",%q,\"[]\",null,null,null,\"generic\"]]", id)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+	client := NewClient(config,
+		WithHTTPClient(&http.Client{}),
+		WithTimeout(20*time.Millisecond),
+		WithTimeoutFor("AHyHrd", 5*time.Second),
+	)
+
+	if _, err := client.Do(RPC{ID: "AHyHrd"}); err != nil {
+		t.Errorf("Do(AHyHrd) error = %v, want per-RPC timeout to apply", err)
+	}
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); err == nil {
+		t.Error("Do(wXbhsf) succeeded, want default timeout to expire")
+	}
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, ")]}'\n\n[[\"wrb.fr\",\"wXbhsf\",%q,null,null,null,\"generic\"]]", strings.Repeat("x", 4096))
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "notebooklm",
+		UseHTTP: true,
+	}
+
+	client := NewClient(config, WithHTTPClient(server.Client()), WithMaxResponseSize(1024))
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Do() error = %v, want ErrResponseTooLarge", err)
+	}
+
+	client = NewClient(config, WithHTTPClient(server.Client()), WithMaxResponseSize(1<<20))
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+		t.Errorf("Do() error = %v", err)
+	}
+}
+
+func TestWithUserIndex(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "LabsTailwindUi",
+		UseHTTP: true,
+	}
+	client := NewClient(config, WithHTTPClient(server.Client()), WithUserIndex(2))
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if want := "/u/2/_/LabsTailwindUi/data/batchexecute"; gotPath != want {
+		t.Errorf("request path = %q, want %q", gotPath, want)
+	}
+}
+
+func TestDecodeEmptyFrame(t *testing.T) {
+	frame := `[[],["wrb.fr","wXbhsf","[1]",null,null,null,"generic"]]`
+	decoders := map[string]func(string) ([]Response, error){
+		"decodeResponse":        decodeResponse,
+		"decodeChunkedResponse": decodeChunkedResponse,
+	}
+	inputs := map[string]string{
+		"decodeResponse":        ")]}'\n" + frame,
+		"decodeChunkedResponse": ")]}'\n" + strconv.Itoa(len(frame)) + "\n" + frame + "\n",
+	}
+	for name, decode := range decoders {
+		t.Run(name, func(t *testing.T) {
+			got, err := decode(inputs[name])
+			if err != nil {
+				t.Fatalf("%s() error = %v", name, err)
+			}
+			if len(got) != 1 || got[0].ID != "wXbhsf" {
+				t.Errorf("%s() = %+v, want the wXbhsf frame only", name, got)
+			}
+		})
+	}
+}
+
+func TestResponseErr(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *RPCError
+	}{
+		{
+			name: "status with localized message",
+			body: `[["wrb.fr","izAoDd",null,null,null,[3,null,[["type.googleapis.com/google.rpc.LocalizedMessage",["en","Invalid source entry."]]]],"generic"]]`,
+			want: &RPCError{Code: 3, Message: "Invalid source entry."},
+		},
+		{
+			name: "bare status",
+			body: `[["wrb.fr","rLM1Ne",null,null,null,[5],"generic"]]`,
+			want: &RPCError{Code: 5},
+		},
+		{
+			name: "er frame",
+			body: `[["er","wXbhsf",null,null,null,400,"Bad Request"]]`,
+			want: &RPCError{Code: 400, Message: "Bad Request"},
+		},
+		{
+			name: "data with status",
+			body: `[["wrb.fr","yR9Yof","[true]",null,null,[2],"generic"]]`,
+		},
+		{
+			name: "data",
+			body: `[["wrb.fr","wXbhsf","[[\"id\"]]",null,null,null,"generic"]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses, err := decodeResponse(tt.body)
+			if err != nil {
+				t.Fatalf("decodeResponse() error = %v", err)
+			}
+			err = responses[0].Err()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Err() = %v, want nil", err)
+				}
+				return
+			}
+			var got *RPCError
+			if !errors.As(err, &got) {
+				t.Fatalf("Err() = %v, want *RPCError", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("Err() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'
+
+[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "LabsTailwindUi",
+		UseHTTP: true,
+	}
+	var calls int
+	base := http.DefaultTransport
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return base.RoundTrip(req)
+	})
+	client := NewClient(config, WithTransport(rt), WithTimeout(5*time.Second))
+	if _, err := client.Do(RPC{ID: "wXbhsf"}); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("transport used %d times, want 1", calls)
+	}
+	if http.DefaultClient.Transport != nil || http.DefaultClient.Timeout != 0 {
+		t.Error("options modified http.DefaultClient")
+	}
+
+	client = NewClient(config, WithMaxIdleConnsPerHost(16))
+	tr, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok || tr.MaxIdleConnsPerHost != 16 || tr == http.DefaultTransport {
+		t.Errorf("WithMaxIdleConnsPerHost(16) transport = %#v", client.httpClient.Transport)
+	}
+}
+
+func TestWithReplayDir(t *testing.T) {
+	dir := t.TempDir()
+	recorded := func(data string) string {
+		return ")]}'\n\n" + `[["wrb.fr","wXbhsf",` + strconv.Quote(data) + `,null,null,null,"generic"]]`
+	}
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("nlm-wXbhsf-20240101T000000.000000000.txt", recorded(`["old"]`))
+	write("nlm-wXbhsf-20240102T000000.000000000.txt", recorded(`["new"]`))
+
+	client := NewClient(Config{Host: "notebooklm.google.com", App: "LabsTailwindUi"}, WithReplayDir(dir))
+	resp, err := client.Do(RPC{ID: "wXbhsf"})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got := string(resp.Data); got != `["new"]` {
+		t.Errorf("Do() data = %s, want the newest debug artifact", got)
+	}
+
+	write("wXbhsf.txt", recorded(`["named"]`))
+	resp, err = client.Do(RPC{ID: "wXbhsf"})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got := string(resp.Data); got != `["named"]` {
+		t.Errorf("Do() data = %s, want the file named after the RPC ID", got)
+	}
+
+	if _, err := client.Do(RPC{ID: "CCqFvf"}); !errors.Is(err, ErrNoRecordedResponse) {
+		t.Errorf("Do() for unrecorded RPC error = %v, want %v", err, ErrNoRecordedResponse)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		var freq [][][]interface{}
+		if err := json.Unmarshal([]byte(r.PostForm.Get("f.req")), &freq); err != nil {
+			t.Error(err)
+		}
+		args := freq[0][0][1].(string)
+		if args == `["bad"]` {
+			fmt.Fprint(w, ")]}'\n\nnot a response")
+			return
+		}
+		fmt.Fprintf(w, ")]}'\n\n"+`[["wrb.fr","wXbhsf",%s,null,null,null,"generic"]]`, strconv.Quote(`{"args":`+args+`}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	live := NewClient(Config{
+		Host:      strings.TrimPrefix(server.URL, "http://"),
+		App:       "LabsTailwindUi",
+		UseHTTP:   true,
+		AuthToken: "secret-token",
+	}, WithRecordDir(dir))
+	for _, arg := range []string{"a", "b"} {
+		if _, err := live.Do(RPC{ID: "wXbhsf", Args: []interface{}{arg}}); err != nil {
+			t.Fatalf("Do(%s) error = %v", arg, err)
+		}
+	}
+	if _, err := live.Do(RPC{ID: "wXbhsf", Args: []interface{}{"bad"}}); err == nil {
+		t.Fatal("Do(bad) succeeded, want a decode error")
+	}
+	for _, pattern := range []string{"wXbhsf-*.txt", "wXbhsf-*.req.json"} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) != 2 {
+			t.Errorf("recorded %d files matching %s, want 2", len(matches), pattern)
+		}
+	}
+	reqs, _ := filepath.Glob(filepath.Join(dir, "*.req.json"))
+	for _, path := range reqs {
+		if b, _ := os.ReadFile(path); strings.Contains(string(b), "secret-token") {
+			t.Errorf("%s contains the auth token", path)
+		}
+	}
+
+	replay := NewClient(Config{Host: "notebooklm.google.com", App: "LabsTailwindUi"}, WithReplayDir(dir))
+	for _, arg := range []string{"b", "a"} {
+		resp, err := replay.Do(RPC{ID: "wXbhsf", Args: []interface{}{arg}})
+		if err != nil {
+			t.Fatalf("replayed Do(%s) error = %v", arg, err)
+		}
+		if want := `{"args":["` + arg + `"]}`; string(resp.Data) != want {
+			t.Errorf("replayed Do(%s) data = %s, want %s", arg, resp.Data, want)
+		}
+	}
+	if _, err := replay.Do(RPC{ID: "wXbhsf", Args: []interface{}{"c"}}); !errors.Is(err, ErrNoRecordedResponse) {
+		t.Errorf("replayed Do(c) error = %v, want %v", err, ErrNoRecordedResponse)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestGzipResponse(t *testing.T) {
+	const payload = `)]}'
+
+[["wrb.fr","wXbhsf","[[\"gzipped\"]]",null,null,null,"generic"]]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			fmt.Fprint(w, payload)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		fmt.Fprint(zw, payload)
+		zw.Close()
+	}))
+	defer server.Close()
+
+	config := Config{
+		Host:    strings.TrimPrefix(server.URL, "http://"),
+		App:     "LabsTailwindUi",
+		UseHTTP: true,
+	}
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"transport decompresses", nil},
+		{"explicit accept-encoding", []Option{WithHeaders(map[string]string{"accept-encoding": "gzip"})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(config, append([]Option{WithHTTPClient(server.Client())}, tt.opts...)...)
+			resp, err := client.Do(RPC{ID: "wXbhsf"})
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if got := string(resp.Data); got != `[["gzipped"]]` {
+				t.Errorf("Data = %s, want %s", got, `[["gzipped"]]`)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package rpc
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/tmc/nlm/internal/batchexecute"
@@ -69,11 +72,158 @@ const (
 	RPCGuidebookGenerateAnswer      = "itA0pc" // GuidebookGenerateAnswer
 )
 
+// rpcNames maps RPC endpoint IDs to their service method names.
+var rpcNames = map[string]string{
+	RPCListRecentlyViewedProjects: "ListRecentlyViewedProjects",
+	RPCCreateProject:              "CreateProject",
+	RPCGetProject:                 "GetProject",
+	RPCDeleteProjects:             "DeleteProjects",
+	RPCMutateProject:              "MutateProject",
+	RPCRemoveRecentlyViewed:       "RemoveRecentlyViewedProject",
+
+	RPCAddSources:           "AddSources",
+	RPCDeleteSources:        "DeleteSources",
+	RPCMutateSource:         "MutateSource",
+	RPCRefreshSource:        "RefreshSource",
+	RPCLoadSource:           "LoadSource",
+	RPCCheckSourceFreshness: "CheckSourceFreshness",
+	RPCActOnSources:         "ActOnSources",
+
+	RPCCreateNote:  "CreateNote",
+	RPCMutateNote:  "MutateNote",
+	RPCDeleteNotes: "DeleteNotes",
+	RPCGetNotes:    "GetNotes",
+
+	RPCCreateAudioOverview: "CreateAudioOverview",
+	RPCGetAudioOverview:    "GetAudioOverview",
+	RPCDeleteAudioOverview: "DeleteAudioOverview",
+
+	RPCGenerateDocumentGuides: "GenerateDocumentGuides",
+	RPCGenerateNotebookGuide:  "GenerateNotebookGuide",
+	RPCGenerateOutline:        "GenerateOutline",
+	RPCGenerateSection:        "GenerateSection",
+	RPCStartDraft:             "StartDraft",
+	RPCStartSection:           "StartSection",
+
+	RPCGetOrCreateAccount: "GetOrCreateAccount",
+	RPCMutateAccount:      "MutateAccount",
+
+	RPCGetProjectAnalytics: "GetProjectAnalytics",
+	RPCSubmitFeedback:      "SubmitFeedback",
+
+	RPCShareAudio:        "ShareAudio",
+	RPCGetProjectDetails: "GetProjectDetails",
+	RPCShareProject:      "ShareProject",
+
+	RPCDeleteGuidebook:              "DeleteGuidebook",
+	RPCGetGuidebook:                 "GetGuidebook",
+	RPCListRecentlyViewedGuidebooks: "ListRecentlyViewedGuidebooks",
+	RPCPublishGuidebook:             "PublishGuidebook",
+	RPCGetGuidebookDetails:          "GetGuidebookDetails",
+	RPCShareGuidebook:               "ShareGuidebook",
+	RPCGuidebookGenerateAnswer:      "GuidebookGenerateAnswer",
+}
+
+// rpcOperations describes what the web UI is doing when it calls each RPC.
+var rpcOperations = map[string]string{
+	RPCListRecentlyViewedProjects: "load the notebook list on the home page",
+	RPCCreateProject:              "create a notebook",
+	RPCGetProject:                 "open a notebook",
+	RPCDeleteProjects:             "delete notebooks",
+	RPCMutateProject:              "rename a notebook or change its emoji",
+	RPCRemoveRecentlyViewed:       "remove a notebook from the recent list",
+
+	RPCAddSources:           "add sources",
+	RPCDeleteSources:        "delete sources",
+	RPCMutateSource:         "rename a source",
+	RPCRefreshSource:        "re-sync a Google Drive source",
+	RPCLoadSource:           "open a source",
+	RPCCheckSourceFreshness: "check whether a Drive source is out of date",
+	RPCActOnSources:         "enable, disable or sync selected sources",
+
+	RPCCreateNote:  "add a note",
+	RPCMutateNote:  "edit a note",
+	RPCDeleteNotes: "delete notes",
+	RPCGetNotes:    "load the notes panel",
+
+	RPCCreateAudioOverview: "generate an audio overview",
+	RPCGetAudioOverview:    "load or play the audio overview",
+	RPCDeleteAudioOverview: "delete the audio overview",
+
+	RPCGenerateDocumentGuides: "show a source's summary and key topics",
+	RPCGenerateNotebookGuide:  "show the notebook guide",
+	RPCGenerateOutline:        "generate an outline",
+	RPCGenerateSection:        "generate a section",
+	RPCStartDraft:             "start a draft",
+	RPCStartSection:           "start a section",
+
+	RPCGetOrCreateAccount: "load account settings",
+	RPCMutateAccount:      "change account settings",
+
+	RPCGetProjectAnalytics: "load notebook analytics",
+	RPCSubmitFeedback:      "send feedback",
+
+	RPCShareAudio:        "share the audio overview",
+	RPCGetProjectDetails: "load sharing details",
+	RPCShareProject:      "share a notebook",
+
+	RPCDeleteGuidebook:              "delete a guidebook",
+	RPCGetGuidebook:                 "open a guidebook",
+	RPCListRecentlyViewedGuidebooks: "load the guidebook list",
+	RPCPublishGuidebook:             "publish a guidebook",
+	RPCGetGuidebookDetails:          "load guidebook details",
+	RPCShareGuidebook:               "share a guidebook",
+	RPCGuidebookGenerateAnswer:      "answer a question in a guidebook",
+}
+
+// Info describes a known RPC endpoint.
+type Info struct {
+	ID        string // batchexecute endpoint ID, e.g. "wXbhsf"
+	Name      string // service method name, e.g. "ListRecentlyViewedProjects"
+	Operation string // what the web UI does with it
+}
+
+// Lookup returns the description of the RPC with the given endpoint ID,
+// and false if the ID is not known.
+func Lookup(id string) (Info, bool) {
+	name, ok := rpcNames[id]
+	if !ok {
+		return Info{ID: id}, false
+	}
+	return Info{ID: id, Name: name, Operation: rpcOperations[id]}, true
+}
+
+// Describe returns a readable label for an endpoint ID for logs and bug
+// reports, such as "wXbhsf (ListRecentlyViewedProjects)". Unknown IDs are
+// returned unchanged.
+func Describe(id string) string {
+	if info, ok := Lookup(id); ok {
+		return fmt.Sprintf("%s (%s)", id, info.Name)
+	}
+	return id
+}
+
+// Known returns every known RPC, sorted by name.
+func Known() []Info {
+	infos := make([]Info, 0, len(rpcNames))
+	for id := range rpcNames {
+		info, _ := Lookup(id)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
 // Call represents a NotebookLM RPC call
 type Call struct {
 	ID         string        // RPC endpoint ID
 	Args       []interface{} // Arguments for the call
 	NotebookID string        // Optional notebook ID for context
+
+	// Headers are extra HTTP headers for this call only, e.g. experiment
+	// flags. Protocol-critical headers such as content-type cannot be
+	// overridden.
+	Headers map[string]string
 }
 
 // Client handles NotebookLM RPC communication
@@ -82,9 +232,17 @@ type Client struct {
 	client *batchexecute.Client
 }
 
-// New creates a new NotebookLM RPC client
+// DefaultFreshnessTimeout bounds CheckSourceFreshness calls, which otherwise
+// share the HTTP client's timeout (none, by default) and can stall a whole
+// freshness check. Override it with batchexecute.WithTimeoutFor.
+const DefaultFreshnessTimeout = 30 * time.Second
+
 // New creates a new NotebookLM RPC client
 func New(authToken, cookies string, options ...batchexecute.Option) *Client {
+	options = append([]batchexecute.Option{
+		batchexecute.WithTimeoutFor(RPCCheckSourceFreshness, DefaultFreshnessTimeout),
+	}, options...)
+
 	config := batchexecute.Config{
 		Host:      "notebooklm.google.com",
 		App:       "LabsTailwindUi",
@@ -109,9 +267,11 @@ func New(authToken, cookies string, options ...batchexecute.Option) *Client {
 		},
 	}
 
-	// Create temporary client to extract debug setting from options
+	// Create temporary client to extract debug and endpoint settings from options
 	tempClient := batchexecute.NewClient(config, options...)
 	config.Debug = tempClient.GetDebug() // We'll need to add this method
+	config.Host = tempClient.Config().Host
+	config.UseHTTP = tempClient.Config().UseHTTP
 
 	return &Client{
 		Config: config,
@@ -119,11 +279,34 @@ func New(authToken, cookies string, options ...batchexecute.Option) *Client {
 	}
 }
 
-// Do executes a NotebookLM RPC call
+// WithCredentials returns a client for another account that shares c's
+// connections and settings. See batchexecute.Client.WithCredentials.
+func (c *Client) WithCredentials(authToken, cookies string) *Client {
+	client := c.client.WithCredentials(authToken, cookies)
+	return &Client{
+		Config: client.Config(),
+		client: client,
+	}
+}
+
+// BaseURL returns the scheme and host RPCs are sent to, e.g.
+// "https://notebooklm.google.com".
+func (c *Client) BaseURL() string {
+	return c.client.BaseURL()
+}
+
+// HTTPClient returns the HTTP client RPCs are sent with, for other requests
+// that should honour the same transport, such as fetching a linked file.
+func (c *Client) HTTPClient() *http.Client {
+	return c.client.HTTPClient()
+}
+
+// Do executes a NotebookLM RPC call. If the server answered with an error
+// status instead of data, the returned error wraps a *batchexecute.RPCError.
 func (c *Client) Do(call Call) (json.RawMessage, error) {
 	if c.Config.Debug {
 		fmt.Printf("\n=== RPC Call ===\n")
-		fmt.Printf("ID: %s\n", call.ID)
+		fmt.Printf("ID: %s\n", Describe(call.ID))
 		fmt.Printf("NotebookID: %s\n", call.NotebookID)
 		fmt.Printf("Args:\n")
 		spew.Dump(call.Args)
@@ -143,9 +326,11 @@ func (c *Client) Do(call Call) (json.RawMessage, error) {
 
 	rpc := batchexecute.RPC{
 		ID:        call.ID,
+		Name:      rpcNames[call.ID],
 		Args:      call.Args,
 		Index:     "generic",
 		URLParams: urlParams,
+		Headers:   call.Headers,
 	}
 
 	if c.Config.Debug {
@@ -157,6 +342,9 @@ func (c *Client) Do(call Call) (json.RawMessage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("execute rpc: %w", err)
 	}
+	if err := resp.Err(); err != nil {
+		return nil, fmt.Errorf("execute rpc %s: %w", Describe(call.ID), err)
+	}
 
 	if c.Config.Debug {
 		fmt.Printf("\nRPC Response:\n")
@@ -171,7 +359,7 @@ func (c *Client) Do(call Call) (json.RawMessage, error) {
 func (c *Client) DoWithFullResponse(call Call) (*batchexecute.Response, error) {
 	if c.Config.Debug {
 		fmt.Printf("\n=== RPC Call (Full Response) ===\n")
-		fmt.Printf("ID: %s\n", call.ID)
+		fmt.Printf("ID: %s\n", Describe(call.ID))
 		fmt.Printf("NotebookID: %s\n", call.NotebookID)
 		fmt.Printf("Args:\n")
 		spew.Dump(call.Args)
@@ -191,9 +379,11 @@ func (c *Client) DoWithFullResponse(call Call) (*batchexecute.Response, error) {
 
 	rpc := batchexecute.RPC{
 		ID:        call.ID,
+		Name:      rpcNames[call.ID],
 		Args:      call.Args,
 		Index:     "generic",
 		URLParams: urlParams,
+		Headers:   call.Headers,
 	}
 
 	if c.Config.Debug {
@@ -214,6 +404,11 @@ func (c *Client) DoWithFullResponse(call Call) (*batchexecute.Response, error) {
 	return resp, nil
 }
 
+// Close releases resources held by the underlying batchexecute client.
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
 // Heartbeat sends a heartbeat to keep the session alive
 func (c *Client) Heartbeat() error {
 	return nil